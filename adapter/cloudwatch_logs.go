@@ -0,0 +1,237 @@
+package adapter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"lambda-to-discord/domain"
+	"lambda-to-discord/i18n"
+	"lambda-to-discord/templates"
+)
+
+const logsAttachmentName = "logs.txt"
+
+type CloudWatchLogsAdapter struct {
+	webhookURL string
+	region     string
+	template   *templates.Message
+	locale     LocaleResolver
+}
+
+func NewCloudWatchLogsAdapter(webhookURL, region string) CloudWatchLogsAdapter {
+	return CloudWatchLogsAdapter{
+		webhookURL: strings.TrimSpace(webhookURL),
+		region:     strings.TrimSpace(region),
+	}
+}
+
+func (a CloudWatchLogsAdapter) WithTemplate(template *templates.Message) CloudWatchLogsAdapter {
+	a.template = template
+	return a
+}
+
+func (a CloudWatchLogsAdapter) WithLocale(locale LocaleResolver) CloudWatchLogsAdapter {
+	a.locale = locale
+	return a
+}
+
+type cloudWatchLogsEvent struct {
+	AWSLogs struct {
+		Data string `json:"data"`
+	} `json:"awslogs"`
+}
+
+type cloudWatchLogsData struct {
+	MessageType         string                   `json:"messageType"`
+	Owner               string                   `json:"owner"`
+	LogGroup            string                   `json:"logGroup"`
+	LogStream           string                   `json:"logStream"`
+	SubscriptionFilters []string                 `json:"subscriptionFilters"`
+	LogEvents           []cloudWatchLogsLogEvent `json:"logEvents"`
+}
+
+type cloudWatchLogsLogEvent struct {
+	ID        string `json:"id"`
+	Timestamp int64  `json:"timestamp"`
+	Message   string `json:"message"`
+}
+
+func (a CloudWatchLogsAdapter) Transform(event json.RawMessage) ([]domain.NotificationPayload, map[string]any, error) {
+	if strings.TrimSpace(a.webhookURL) == "" {
+		return nil, nil, errors.New("cloudwatch logs adapter requires webhook url")
+	}
+
+	raw, err := decodeCloudWatchLogsData(event)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var eventMap map[string]any
+	if err := json.Unmarshal(raw, &eventMap); err != nil {
+		eventMap = map[string]any{"raw": string(raw)}
+	}
+
+	var data cloudWatchLogsData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, eventMap, fmt.Errorf("failed to decode cloudwatch logs data: %w", err)
+	}
+	if data.MessageType == "CONTROL_MESSAGE" {
+		return nil, eventMap, nil
+	}
+
+	attrs := attributes(
+		domain.AttributeLogGroup, data.LogGroup,
+		domain.AttributeAccount, data.Owner,
+		domain.AttributeRegion, a.region,
+	)
+	catalog := a.locale.catalog(attrs, eventMap)
+	attrs[domain.AttributeLocale] = catalog.Language()
+
+	embed := domain.Embed{
+		Title:       data.LogGroup,
+		Description: buildLogEventsBlock(data.LogEvents),
+		Fields:      a.buildLogFields(data, catalog),
+		Color:       0xE67E22,
+	}
+	var attachments []domain.Attachment
+	if utf8.RuneCountInString(embed.Description) > domain.MaxEmbedDescriptionLength {
+		embed.Description = buildLogExcerpt(data.LogEvents, domain.MaxEmbedDescriptionLength, catalog)
+		attachments = []domain.Attachment{{
+			Name:        logsAttachmentName,
+			ContentType: "text/plain; charset=utf-8",
+			Data:        []byte(strings.Join(logMessages(data.LogEvents), "\n") + "\n"),
+		}}
+	}
+	if len(data.LogEvents) > 0 && data.LogEvents[0].Timestamp > 0 {
+		embed.Timestamp = time.UnixMilli(data.LogEvents[0].Timestamp).UTC().Format(time.RFC3339Nano)
+	}
+
+	payload := domain.NotificationPayload{
+		WebhookURL:      a.webhookURL,
+		Content:         a.template.Content(eventMap, catalog.T(i18n.LogsMatched, len(data.LogEvents), data.LogGroup)),
+		Embeds:          []domain.Embed{a.template.Embed(embed, eventMap)},
+		AllowedMentions: domain.NoMentions(),
+		Attachments:     attachments,
+		Attributes:      attrs,
+	}
+
+	return []domain.NotificationPayload{payload}, eventMap, nil
+}
+
+func decodeCloudWatchLogsData(event json.RawMessage) ([]byte, error) {
+	var envelope cloudWatchLogsEvent
+	if err := json.Unmarshal(bytes.TrimSpace(event), &envelope); err != nil {
+		return nil, fmt.Errorf("failed to decode cloudwatch logs event: %w", err)
+	}
+	encoded := strings.TrimSpace(envelope.AWSLogs.Data)
+	if encoded == "" {
+		return nil, errors.New("cloudwatch logs event must contain 'awslogs.data'")
+	}
+
+	compressed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode awslogs.data as base64: %w", err)
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress awslogs.data: %w", err)
+	}
+	defer reader.Close()
+
+	decompressed, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress awslogs.data: %w", err)
+	}
+	return decompressed, nil
+}
+
+func logMessages(events []cloudWatchLogsLogEvent) []string {
+	lines := make([]string, 0, len(events))
+	for _, event := range events {
+		lines = append(lines, strings.TrimRight(event.Message, "\r\n"))
+	}
+	return lines
+}
+
+func buildLogEventsBlock(events []cloudWatchLogsLogEvent) string {
+	if len(events) == 0 {
+		return ""
+	}
+	return formatLogBlock(logMessages(events))
+}
+
+func buildLogExcerpt(events []cloudWatchLogsLogEvent, limit int, catalog i18n.Catalog) string {
+	suffix := catalog.T(i18n.LogsAttached, logsAttachmentName)
+	budget := limit - utf8.RuneCountInString(formatLogBlock(nil)+suffix)
+
+	var lines []string
+	for _, line := range logMessages(events) {
+		n := utf8.RuneCountInString(line) + 1
+		if n > budget {
+			if len(lines) == 0 && budget > 0 {
+				lines = append(lines, string([]rune(line)[:budget-1]))
+			}
+			break
+		}
+		lines = append(lines, line)
+		budget -= n
+	}
+	return formatLogBlock(lines) + suffix
+}
+
+func formatLogBlock(lines []string) string {
+	escaped := make([]string, 0, len(lines))
+	for _, line := range lines {
+		escaped = append(escaped, strings.ReplaceAll(line, "```", "'''"))
+	}
+	return fmt.Sprintf("```\n%s\n```", strings.Join(escaped, "\n"))
+}
+
+func (a CloudWatchLogsAdapter) buildLogFields(data cloudWatchLogsData, catalog i18n.Catalog) []domain.EmbedField {
+	var fields []domain.EmbedField
+	appendField := func(name, value string, inline bool) {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return
+		}
+		fields = append(fields, domain.EmbedField{Name: name, Value: value, Inline: inline})
+	}
+
+	logGroup := data.LogGroup
+	if link := a.logGroupConsoleURL(data.LogGroup); link != "" {
+		logGroup = fmt.Sprintf("[%s](%s)", data.LogGroup, link)
+	}
+	appendField(catalog.T(i18n.FieldLogGroup), logGroup, false)
+	appendField(catalog.T(i18n.FieldLogStream), data.LogStream, false)
+	appendField(catalog.T(i18n.FieldSubscriptionFilter), strings.Join(data.SubscriptionFilters, ", "), true)
+	appendField(catalog.T(i18n.FieldAccount), data.Owner, true)
+	appendField(catalog.T(i18n.FieldEvents), fmt.Sprint(len(data.LogEvents)), true)
+
+	return fields
+}
+
+func (a CloudWatchLogsAdapter) logGroupConsoleURL(logGroup string) string {
+	if a.region == "" || strings.TrimSpace(logGroup) == "" {
+		return ""
+	}
+	escaped := strings.ReplaceAll(url.QueryEscape(logGroup), "%", "$25")
+	return fmt.Sprintf("https://%s.console.aws.amazon.com/cloudwatch/home?region=%s#logsV2:log-groups/log-group/%s", a.region, a.region, escaped)
+}
+
+func detectCloudWatchLogs(event json.RawMessage) bool {
+	var envelope cloudWatchLogsEvent
+	if err := json.Unmarshal(bytes.TrimSpace(event), &envelope); err != nil {
+		return false
+	}
+	return strings.TrimSpace(envelope.AWSLogs.Data) != ""
+}
@@ -0,0 +1,129 @@
+package adapter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const sampleLogsData = `{
+  "messageType": "DATA_MESSAGE",
+  "owner": "123456789012",
+  "logGroup": "/aws/lambda/orders",
+  "logStream": "2024/01/02/[$LATEST]abcdef",
+  "subscriptionFilters": ["errors"],
+  "logEvents": [
+    {"id": "1", "timestamp": 1704164645000, "message": "ERROR failed to charge card\n"},
+    {"id": "2", "timestamp": 1704164646000, "message": "ERROR retry exhausted"}
+  ]
+}`
+
+func encodeLogsEvent(t *testing.T, data string) json.RawMessage {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write([]byte(data)); err != nil {
+		t.Fatalf("failed to compress: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to compress: %v", err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(buf.Bytes())
+	return json.RawMessage(`{"awslogs":{"data":"` + encoded + `"}}`)
+}
+
+func TestCloudWatchLogsAdapterTransform(t *testing.T) {
+	adapter := NewCloudWatchLogsAdapter("https://discord.com/api/webhooks/900/logs", "ap-northeast-1")
+
+	payloads, eventMap, err := adapter.Transform(encodeLogsEvent(t, sampleLogsData))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	payload := singlePayload(t, payloads)
+	if payload.WebhookURL != "https://discord.com/api/webhooks/900/logs" {
+		t.Fatalf("unexpected webhook: %s", payload.WebhookURL)
+	}
+	if len(payload.Embeds) != 1 {
+		t.Fatalf("expected a single embed, got %d", len(payload.Embeds))
+	}
+	embed := payload.Embeds[0]
+	if embed.Title != "/aws/lambda/orders" {
+		t.Fatalf("unexpected title: %s", embed.Title)
+	}
+	want := "```\nERROR failed to charge card\nERROR retry exhausted\n```"
+	if embed.Description != want {
+		t.Fatalf("unexpected description: %q", embed.Description)
+	}
+	if embed.Timestamp != "2024-01-02T03:04:05Z" {
+		t.Fatalf("unexpected timestamp: %s", embed.Timestamp)
+	}
+	if len(embed.Fields) == 0 || embed.Fields[0].Name != "Log Group" {
+		t.Fatalf("expected log group field: %#v", embed.Fields)
+	}
+	link := "https://ap-northeast-1.console.aws.amazon.com/cloudwatch/home?region=ap-northeast-1#logsV2:log-groups/log-group/$252Faws$252Flambda$252Forders"
+	if !strings.Contains(embed.Fields[0].Value, "("+link+")") {
+		t.Fatalf("unexpected log group link: %s", embed.Fields[0].Value)
+	}
+	if eventMap["logStream"] != "2024/01/02/[$LATEST]abcdef" {
+		t.Fatalf("expected decoded data in event map: %#v", eventMap)
+	}
+}
+
+func TestCloudWatchLogsAdapterControlMessage(t *testing.T) {
+	raw := encodeLogsEvent(t, `{"messageType":"CONTROL_MESSAGE","logEvents":[]}`)
+	payloads, _, err := NewCloudWatchLogsAdapter("https://discord.com/api/webhooks/900/logs", "").Transform(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(payloads) != 0 {
+		t.Fatalf("expected control message to be skipped, got %#v", payloads)
+	}
+}
+
+func TestCloudWatchLogsAdapterErrors(t *testing.T) {
+	if _, _, err := NewCloudWatchLogsAdapter("", "").Transform(encodeLogsEvent(t, sampleLogsData)); err == nil {
+		t.Fatal("expected error when webhook missing")
+	}
+	adapter := NewCloudWatchLogsAdapter("https://discord.com/api/webhooks/100/hook", "")
+	if _, _, err := adapter.Transform(json.RawMessage(`{"content":"hi"}`)); err == nil {
+		t.Fatal("expected error when awslogs.data missing")
+	}
+	if _, _, err := adapter.Transform(json.RawMessage(`{"awslogs":{"data":"not base64!"}}`)); err == nil {
+		t.Fatal("expected error for invalid base64")
+	}
+	if _, _, err := adapter.Transform(json.RawMessage(`{"awslogs":{"data":"aGVsbG8="}}`)); err == nil {
+		t.Fatal("expected error for data that is not gzip")
+	}
+}
+
+func TestCloudWatchLogsAdapterAttachesOversizedLogs(t *testing.T) {
+	line := strings.Repeat("x", 200)
+	var events []string
+	for i := 0; i < 40; i++ {
+		events = append(events, `{"id":"`+fmt.Sprint(i)+`","timestamp":1704164645000,"message":"`+line+`"}`)
+	}
+	data := `{"messageType":"DATA_MESSAGE","logGroup":"/aws/lambda/orders","logEvents":[` + strings.Join(events, ",") + `]}`
+
+	payloads, _, err := NewCloudWatchLogsAdapter("https://discord.com/api/webhooks/900/logs", "").Transform(encodeLogsEvent(t, data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	payload := singlePayload(t, payloads)
+	if err := payload.Validate(); err != nil {
+		t.Fatalf("expected payload within limits: %v", err)
+	}
+	description := payload.Embeds[0].Description
+	if !strings.HasPrefix(description, "```\n"+line) || !strings.HasSuffix(description, "…full log attached as logs.txt") {
+		t.Fatalf("unexpected excerpt: %q", description)
+	}
+	if len(payload.Attachments) != 1 || payload.Attachments[0].Name != "logs.txt" {
+		t.Fatalf("expected logs attachment: %#v", payload.Attachments)
+	}
+	if got := strings.Count(string(payload.Attachments[0].Data), line); got != 40 {
+		t.Fatalf("expected every log line in the attachment, got %d", got)
+	}
+}
@@ -5,63 +5,366 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
+	"regexp"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"lambda-to-discord/domain"
+	"lambda-to-discord/i18n"
+	"lambda-to-discord/templates"
+)
+
+var (
+	regionCodePattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d+$`)
+	alarmLinkPattern  = regexp.MustCompile(`(?i)\b(runbook|dashboard)\s*[:=]\s*(https?://[^\s<>()]+)`)
 )
 
 type CloudWatchSNSAdapter struct {
-	webhookURL string
+	webhookURL     string
+	combineRecords bool
+	alarmThreads   bool
+	threadIDs      map[string]string
+	silentStates   map[string]bool
+	template       *templates.Message
+	locale         LocaleResolver
+	location       *time.Location
 }
 
 func NewCloudWatchSNSAdapter(webhookURL string) CloudWatchSNSAdapter {
 	return CloudWatchSNSAdapter{webhookURL: strings.TrimSpace(webhookURL)}
 }
 
-func (a CloudWatchSNSAdapter) Transform(event json.RawMessage) (domain.NotificationPayload, map[string]any, error) {
+func (a CloudWatchSNSAdapter) WithCombinedRecords(combine bool) CloudWatchSNSAdapter {
+	a.combineRecords = combine
+	return a
+}
+
+func (a CloudWatchSNSAdapter) WithAlarmThreads(create bool, threadIDs map[string]string) CloudWatchSNSAdapter {
+	a.alarmThreads = create
+	a.threadIDs = threadIDs
+	return a
+}
+
+func (a CloudWatchSNSAdapter) WithSilentStates(states []string) CloudWatchSNSAdapter {
+	a.silentStates = map[string]bool{}
+	for _, state := range states {
+		if state = strings.ToUpper(strings.TrimSpace(state)); state != "" {
+			a.silentStates[state] = true
+		}
+	}
+	return a
+}
+
+func (a CloudWatchSNSAdapter) WithTemplate(template *templates.Message) CloudWatchSNSAdapter {
+	a.template = template
+	return a
+}
+
+func (a CloudWatchSNSAdapter) WithLocale(locale LocaleResolver) CloudWatchSNSAdapter {
+	a.locale = locale
+	return a
+}
+
+func (a CloudWatchSNSAdapter) WithDisplayLocation(location *time.Location) CloudWatchSNSAdapter {
+	a.location = location
+	return a
+}
+
+func (a CloudWatchSNSAdapter) isSilent(alarm cloudWatchAlarm) bool {
+	return a.silentStates[strings.ToUpper(strings.TrimSpace(alarm.NewStateValue))]
+}
+
+func (a CloudWatchSNSAdapter) Transform(event json.RawMessage) ([]domain.NotificationPayload, map[string]any, error) {
 	if strings.TrimSpace(a.webhookURL) == "" {
-		return domain.NotificationPayload{}, nil, errors.New("cloudwatch adapter requires webhook url")
+		return nil, nil, errors.New("cloudwatch adapter requires webhook url")
 	}
 
-	message, err := extractAlarmMessage(event)
+	messages, err := extractAlarmMessages(event)
 	if err != nil {
-		return domain.NotificationPayload{}, nil, err
+		return nil, nil, err
 	}
 
-	alarm, err := decodeAlarm(message)
-	if err != nil {
-		return domain.NotificationPayload{}, nil, err
+	var (
+		alarms    []cloudWatchAlarm
+		eventMaps []any
+		errs      []error
+	)
+	for i, message := range messages {
+		eventMaps = append(eventMaps, decodeEventMap(message))
+
+		alarm, err := decodeAlarm(message)
+		if err != nil {
+			if len(messages) > 1 {
+				err = fmt.Errorf("record %d: %w", i, err)
+			}
+			errs = append(errs, err)
+			continue
+		}
+		alarm.raw, _ = eventMaps[i].(map[string]any)
+		alarms = append(alarms, alarm)
 	}
 
+	var eventMap map[string]any
+	if len(eventMaps) == 1 {
+		eventMap, _ = eventMaps[0].(map[string]any)
+	} else {
+		eventMap = map[string]any{"Records": eventMaps}
+	}
+
+	var payloads []domain.NotificationPayload
+	if a.combineRecords && len(alarms) > 1 {
+		payloads = append(payloads, a.buildCombinedPayload(alarms))
+	} else {
+		for _, alarm := range alarms {
+			payloads = append(payloads, a.buildPayload(alarm))
+		}
+	}
+
+	return payloads, eventMap, errors.Join(errs...)
+}
+
+func (a CloudWatchSNSAdapter) buildPayload(alarm cloudWatchAlarm) domain.NotificationPayload {
+	attributes := alarmAttributes(alarm)
+	catalog := a.locale.catalog(attributes, alarm.raw)
 	payload := domain.NotificationPayload{
 		WebhookURL:      a.webhookURL,
-		Content:         buildAlarmSummary(alarm),
+		Content:         a.template.Content(alarm.raw, buildAlarmSummary(alarm, catalog)),
+		Embeds:          []domain.Embed{a.template.Embed(a.buildAlarmEmbed(alarm, catalog), alarm.raw)},
 		AllowedMentions: domain.NoMentions(),
+		Components:      buildAlarmComponents(alarm, catalog),
+	}
+	if a.isSilent(alarm) {
+		payload.Flags |= domain.FlagSuppressNotifications
 	}
+	if threadID := strings.TrimSpace(a.threadIDs[alarm.AlarmName]); threadID != "" {
+		payload.ThreadID = threadID
+	} else if a.alarmThreads {
+		payload.ThreadName = alarm.AlarmName
+		payload.ThreadKey = alarmKey(alarm)
+	}
+	payload.Correlation = alarmCorrelation(alarm)
+	payload.Attributes = attributes
+	payload.Event = alarm.raw
+	return payload.WithAttribute(domain.AttributeLocale, catalog.Language())
+}
 
-	embed := domain.Embed{
-		Title:       alarm.AlarmName,
-		Description: buildAlarmDescription(alarm),
-		Fields:      buildAlarmFields(alarm),
-		Timestamp:   alarm.StateChangeTime,
+func alarmAttributes(alarm cloudWatchAlarm) map[string]string {
+	return attributes(
+		domain.AttributeAlarmName, alarm.AlarmName,
+		domain.AttributeNamespace, alarm.Trigger.Namespace,
+		domain.AttributeMetricName, alarm.Trigger.MetricName,
+		domain.AttributeAccount, alarm.AWSAccountID,
+		domain.AttributeRegion, alarmRegion(alarm),
+		domain.AttributeState, strings.ToUpper(alarm.NewStateValue),
+	)
+}
+
+func alarmKey(alarm cloudWatchAlarm) string {
+	if key := strings.TrimSpace(alarm.AlarmArn); key != "" {
+		return key
+	}
+	return strings.TrimSpace(alarm.AlarmName)
+}
+
+func alarmCorrelation(alarm cloudWatchAlarm) *domain.Correlation {
+	key := alarmKey(alarm)
+	if key == "" {
+		return nil
 	}
+
+	var kind domain.CorrelationKind
 	switch strings.ToUpper(strings.TrimSpace(alarm.NewStateValue)) {
 	case "ALARM":
-		embed.Color = 0xE74C3C
+		kind = domain.CorrelationOpen
 	case "OK":
-		embed.Color = 0x2ECC71
+		kind = domain.CorrelationResolve
+	default:
+		return nil
+	}
+
+	return &domain.Correlation{
+		Key:        key,
+		Kind:       kind,
+		OccurredAt: parseStateChangeTime(alarm.StateChangeTime),
+	}
+}
+
+func parseStateChangeTime(value string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05Z0700"} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed
+		}
+	}
+	return time.Time{}
+}
+
+func formatChangedAt(changedAt time.Time, location *time.Location) string {
+	if location == nil {
+		location = time.UTC
+	}
+	return fmt.Sprintf("%s (<t:%d:R>)", changedAt.In(location).Format("2006-01-02 15:04:05 MST"), changedAt.Unix())
+}
+
+func (a CloudWatchSNSAdapter) buildCombinedPayload(alarms []cloudWatchAlarm) domain.NotificationPayload {
+	payload := domain.NotificationPayload{
+		WebhookURL:      a.webhookURL,
+		AllowedMentions: domain.NoMentions(),
+	}
+	silent := true
+	for i, alarm := range alarms {
+		silent = silent && a.isSilent(alarm)
+		if i == 0 {
+			payload.Attributes = alarmAttributes(alarm)
+			continue
+		}
+		current := alarmAttributes(alarm)
+		for name, value := range payload.Attributes {
+			if current[name] != value {
+				delete(payload.Attributes, name)
+			}
+		}
+	}
+	if silent {
+		payload.Flags |= domain.FlagSuppressNotifications
+	}
+
+	catalog := a.locale.catalog(payload.Attributes, nil)
+	payload.Content = catalog.T(i18n.AlarmsChanged, len(alarms))
+	for _, alarm := range alarms {
+		payload.Embeds = append(payload.Embeds, a.template.Embed(a.buildAlarmEmbed(alarm, catalog), alarm.raw))
+	}
+	return payload.WithAttribute(domain.AttributeLocale, catalog.Language())
+}
+
+func (a CloudWatchSNSAdapter) buildAlarmEmbed(alarm cloudWatchAlarm, catalog i18n.Catalog) domain.Embed {
+	embed := domain.Embed{
+		Title:       alarm.AlarmName,
+		URL:         alarmConsoleURL(alarm),
+		Description: buildAlarmDescription(alarm, catalog),
+		Fields:      buildAlarmFields(alarm, catalog),
+		Color:       alarmColor(alarm.NewStateValue),
+	}
+	if changedAt := parseStateChangeTime(alarm.StateChangeTime); !changedAt.IsZero() {
+		embed.Timestamp = changedAt.UTC().Format(time.RFC3339Nano)
+		embed.Fields = append(embed.Fields, domain.EmbedField{
+			Name:  catalog.T(i18n.FieldChangedAt),
+			Value: formatChangedAt(changedAt, a.location),
+		})
 	}
 	if desc := strings.TrimSpace(alarm.AlarmDescription); desc != "" {
 		embed.Footer = &domain.EmbedFooter{Text: desc}
 	}
-	payload.Embeds = append(payload.Embeds, embed)
+	return embed
+}
+
+func alarmConsoleURL(alarm cloudWatchAlarm) string {
+	name := strings.TrimSpace(alarm.AlarmName)
+	base := cloudWatchConsoleURL(alarm)
+	if name == "" || base == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s#alarmsV2:alarm/%s", base, url.PathEscape(name))
+}
+
+func metricConsoleURL(alarm cloudWatchAlarm) string {
+	trigger := alarm.Trigger
+	base := cloudWatchConsoleURL(alarm)
+	if base == "" || trigger.Namespace == "" || trigger.MetricName == "" {
+		return ""
+	}
 
+	metric := []string{jsurlString(trigger.Namespace), jsurlString(trigger.MetricName)}
+	for _, dim := range trigger.Dimensions {
+		if dim.Name == "" || dim.Value == "" {
+			continue
+		}
+		metric = append(metric, jsurlString(dim.Name), jsurlString(dim.Value))
+	}
+	return fmt.Sprintf("%s#metricsV2:graph=~(metrics~(~(~%s))~region~%s)", base, strings.Join(metric, "~"), jsurlString(alarmRegion(alarm)))
+}
+
+func cloudWatchConsoleURL(alarm cloudWatchAlarm) string {
+	region := alarmRegion(alarm)
+	if region == "" {
+		return ""
+	}
+
+	host := "console.aws.amazon.com"
+	switch alarmPartition(alarm) {
+	case "aws-cn":
+		host = "console.amazonaws.cn"
+	case "aws-us-gov":
+		host = "console.amazonaws-us-gov.com"
+	}
+	return fmt.Sprintf("https://%s.%s/cloudwatch/home?region=%s", region, host, region)
+}
+
+func alarmRegion(alarm cloudWatchAlarm) string {
+	if arn := strings.Split(strings.TrimSpace(alarm.AlarmArn), ":"); len(arn) > 3 && arn[0] == "arn" && arn[3] != "" {
+		return arn[3]
+	}
+	if region := strings.TrimSpace(alarm.Region); regionCodePattern.MatchString(region) {
+		return region
+	}
+	return ""
+}
+
+func alarmPartition(alarm cloudWatchAlarm) string {
+	if arn := strings.Split(strings.TrimSpace(alarm.AlarmArn), ":"); len(arn) > 1 && arn[0] == "arn" {
+		return arn[1]
+	}
+	return "aws"
+}
+
+func jsurlString(value string) string {
+	var b strings.Builder
+	b.WriteByte('\'')
+	for _, r := range value {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			b.WriteRune(r)
+		case r == '$':
+			b.WriteByte('!')
+		case r < 0x100:
+			fmt.Fprintf(&b, "*%02x", r)
+		default:
+			fmt.Fprintf(&b, "**%04x", r)
+		}
+	}
+	return b.String()
+}
+
+func buildAlarmComponents(alarm cloudWatchAlarm, catalog i18n.Catalog) []domain.Component {
+	var buttons []domain.Component
+	addButton := func(label, link string) {
+		if link != "" && utf8.RuneCountInString(link) <= domain.MaxButtonURLLength {
+			buttons = append(buttons, domain.LinkButton(label, link))
+		}
+	}
+	addButton(catalog.T(i18n.ButtonOpenAlarm), alarmConsoleURL(alarm))
+	addButton(catalog.T(i18n.ButtonViewMetric), metricConsoleURL(alarm))
+	for _, match := range alarmLinkPattern.FindAllStringSubmatch(alarm.AlarmDescription, -1) {
+		label := catalog.T(i18n.ButtonRunbook)
+		if strings.EqualFold(match[1], "dashboard") {
+			label = catalog.T(i18n.ButtonDashboard)
+		}
+		addButton(label, match[2])
+	}
+	if len(buttons) == 0 {
+		return nil
+	}
+	return []domain.Component{domain.ActionRow(buttons[:min(len(buttons), domain.MaxActionRowComponents)]...)}
+}
+
+func decodeEventMap(message json.RawMessage) any {
 	var eventMap map[string]any
 	if err := json.Unmarshal(message, &eventMap); err != nil {
-		eventMap = map[string]any{"raw": string(message)}
+		return map[string]any{"raw": string(message)}
 	}
-
-	return payload, eventMap, nil
+	return eventMap
 }
 
 type snsEnvelope struct {
@@ -72,6 +375,11 @@ type snsEnvelope struct {
 	} `json:"Records"`
 }
 
+type snsNotification struct {
+	Type    string `json:"Type"`
+	Message string `json:"Message"`
+}
+
 type cloudWatchAlarm struct {
 	AlarmName        string            `json:"AlarmName"`
 	AlarmDescription string            `json:"AlarmDescription"`
@@ -83,6 +391,8 @@ type cloudWatchAlarm struct {
 	AlarmArn         string            `json:"AlarmArn"`
 	OldStateValue    string            `json:"OldStateValue"`
 	Trigger          cloudWatchTrigger `json:"Trigger"`
+
+	raw map[string]any
 }
 
 type cloudWatchTrigger struct {
@@ -103,7 +413,7 @@ type cloudWatchDimension struct {
 	Value string `json:"value"`
 }
 
-func extractAlarmMessage(event json.RawMessage) (json.RawMessage, error) {
+func extractAlarmMessages(event json.RawMessage) ([]json.RawMessage, error) {
 	trimmed := bytes.TrimSpace(event)
 	if len(trimmed) == 0 {
 		return nil, errors.New("cloudwatch alarm message is empty")
@@ -111,17 +421,26 @@ func extractAlarmMessage(event json.RawMessage) (json.RawMessage, error) {
 
 	var asString string
 	if err := json.Unmarshal(trimmed, &asString); err == nil {
-		return json.RawMessage(strings.TrimSpace(asString)), nil
+		return []json.RawMessage{json.RawMessage(strings.TrimSpace(asString))}, nil
 	}
 
 	var envelope snsEnvelope
-	if err := json.Unmarshal(trimmed, &envelope); err == nil {
-		if len(envelope.Records) > 0 && envelope.Records[0].Sns.Message != "" {
-			return json.RawMessage(envelope.Records[0].Sns.Message), nil
+	if err := json.Unmarshal(trimmed, &envelope); err == nil && len(envelope.Records) > 0 {
+		messages := make([]json.RawMessage, 0, len(envelope.Records))
+		for _, record := range envelope.Records {
+			messages = append(messages, json.RawMessage(record.Sns.Message))
+		}
+		return messages, nil
+	}
+
+	var notification snsNotification
+	if err := json.Unmarshal(trimmed, &notification); err == nil {
+		if notification.Type == "Notification" && notification.Message != "" {
+			return []json.RawMessage{json.RawMessage(notification.Message)}, nil
 		}
 	}
 
-	return trimmed, nil
+	return []json.RawMessage{trimmed}, nil
 }
 
 func decodeAlarm(raw json.RawMessage) (cloudWatchAlarm, error) {
@@ -138,22 +457,32 @@ func decodeAlarm(raw json.RawMessage) (cloudWatchAlarm, error) {
 	return alarm, nil
 }
 
-func buildAlarmSummary(alarm cloudWatchAlarm) string {
-	state := strings.ToLower(strings.TrimSpace(alarm.NewStateValue))
+func alarmColor(state string) int {
+	switch strings.ToUpper(strings.TrimSpace(state)) {
+	case "ALARM":
+		return 0xE74C3C
+	case "OK":
+		return 0x2ECC71
+	}
+	return 0
+}
+
+func buildAlarmSummary(alarm cloudWatchAlarm, catalog i18n.Catalog) string {
+	state := strings.ToLower(catalog.State(alarm.NewStateValue))
 	if state == "" {
-		state = "unknown"
+		state = catalog.T(i18n.StateUnknown)
 	}
-	return fmt.Sprintf(":rotating_light: CloudWatch alarm %q is %s", alarm.AlarmName, state)
+	return catalog.T(i18n.AlarmSummary, alarm.AlarmName, state)
 }
 
-func buildAlarmDescription(alarm cloudWatchAlarm) string {
+func buildAlarmDescription(alarm cloudWatchAlarm, catalog i18n.Catalog) string {
 	if desc := strings.TrimSpace(alarm.NewStateReason); desc != "" {
 		return desc
 	}
-	return "CloudWatch reported a state change."
+	return catalog.T(i18n.AlarmDefaultDescription)
 }
 
-func buildAlarmFields(alarm cloudWatchAlarm) []domain.EmbedField {
+func buildAlarmFields(alarm cloudWatchAlarm, catalog i18n.Catalog) []domain.EmbedField {
 	var fields []domain.EmbedField
 	appendField := func(name, value string, inline bool) {
 		value = strings.TrimSpace(value)
@@ -163,23 +492,23 @@ func buildAlarmFields(alarm cloudWatchAlarm) []domain.EmbedField {
 		fields = append(fields, domain.EmbedField{Name: name, Value: value, Inline: inline})
 	}
 
-	appendField("Account", alarm.AWSAccountID, true)
-	appendField("Region", alarm.Region, true)
-	appendField("Old State", alarm.OldStateValue, true)
-	appendField("New State", alarm.NewStateValue, true)
-	appendField("Alarm ARN", alarm.AlarmArn, false)
+	appendField(catalog.T(i18n.FieldAccount), alarm.AWSAccountID, true)
+	appendField(catalog.T(i18n.FieldRegion), alarm.Region, true)
+	appendField(catalog.T(i18n.FieldOldState), catalog.State(alarm.OldStateValue), true)
+	appendField(catalog.T(i18n.FieldNewState), catalog.State(alarm.NewStateValue), true)
+	appendField(catalog.T(i18n.FieldAlarmARN), alarm.AlarmArn, false)
 
-	if metric := buildMetricSummary(alarm.Trigger); metric != "" {
-		appendField("Trigger", metric, false)
+	if metric := buildMetricSummary(alarm.Trigger, catalog); metric != "" {
+		appendField(catalog.T(i18n.FieldTrigger), metric, false)
 	}
 	if dimensions := buildDimensionsSummary(alarm.Trigger.Dimensions); dimensions != "" {
-		appendField("Dimensions", dimensions, false)
+		appendField(catalog.T(i18n.FieldDimensions), dimensions, false)
 	}
 
 	return fields
 }
 
-func buildMetricSummary(trigger cloudWatchTrigger) string {
+func buildMetricSummary(trigger cloudWatchTrigger, catalog i18n.Catalog) string {
 	parts := []string{}
 	if trigger.Namespace != "" && trigger.MetricName != "" {
 		parts = append(parts, fmt.Sprintf("%s/%s", trigger.Namespace, trigger.MetricName))
@@ -196,13 +525,13 @@ func buildMetricSummary(trigger cloudWatchTrigger) string {
 		parts = append(parts, fmt.Sprintf("%s %s", trigger.ComparisonOperator, threshold))
 	}
 	if trigger.EvaluationPeriods > 0 {
-		parts = append(parts, fmt.Sprintf("for %d periods", trigger.EvaluationPeriods))
+		parts = append(parts, catalog.T(i18n.AlarmEvaluationPeriods, trigger.EvaluationPeriods))
 	}
 	if trigger.Period > 0 {
-		parts = append(parts, fmt.Sprintf("period: %ds", trigger.Period))
+		parts = append(parts, catalog.T(i18n.AlarmPeriod, trigger.Period))
 	}
 	if trigger.TreatMissingData != "" {
-		parts = append(parts, fmt.Sprintf("missing data: %s", trigger.TreatMissingData))
+		parts = append(parts, catalog.T(i18n.AlarmMissingData, trigger.TreatMissingData))
 	}
 	return strings.Join(parts, " · ")
 }
@@ -222,3 +551,15 @@ func buildDimensionsSummary(dimensions []cloudWatchDimension) string {
 	}
 	return strings.Join(parts, ", ")
 }
+
+func detectCloudWatchAlarm(event json.RawMessage) bool {
+	messages, err := extractAlarmMessages(event)
+	if err != nil || len(messages) == 0 {
+		return false
+	}
+	var alarm cloudWatchAlarm
+	if err := json.Unmarshal(bytes.TrimSpace(messages[0]), &alarm); err != nil {
+		return false
+	}
+	return alarm.AlarmName != "" && alarm.NewStateValue != ""
+}
@@ -2,7 +2,13 @@ package adapter
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
+	"time"
+
+	"lambda-to-discord/domain"
+	"lambda-to-discord/i18n"
+	"lambda-to-discord/templates"
 )
 
 const sampleAlarmMessage = `{
@@ -33,13 +39,14 @@ const sampleAlarmMessage = `{
 
 func TestCloudWatchSNSAdapterTransform(t *testing.T) {
 	raw := json.RawMessage(sampleAlarmMessage)
-	adapter := NewCloudWatchSNSAdapter("https://discord.example/cloudwatch")
+	adapter := NewCloudWatchSNSAdapter("https://discord.com/api/webhooks/200/cloudwatch")
 
-	payload, eventMap, err := adapter.Transform(raw)
+	payloads, eventMap, err := adapter.Transform(raw)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if payload.WebhookURL != "https://discord.example/cloudwatch" {
+	payload := singlePayload(t, payloads)
+	if payload.WebhookURL != "https://discord.com/api/webhooks/200/cloudwatch" {
 		t.Fatalf("unexpected webhook: %s", payload.WebhookURL)
 	}
 	if payload.AllowedMentions == nil || len(payload.AllowedMentions.Parse) != 0 {
@@ -52,6 +59,9 @@ func TestCloudWatchSNSAdapterTransform(t *testing.T) {
 	if embed.Title != "CPUHigh" {
 		t.Fatalf("unexpected title: %s", embed.Title)
 	}
+	if embed.URL != "https://us-east-1.console.aws.amazon.com/cloudwatch/home?region=us-east-1#alarmsV2:alarm/CPUHigh" {
+		t.Fatalf("unexpected url: %s", embed.URL)
+	}
 	if len(embed.Fields) == 0 {
 		t.Fatalf("expected embed fields to be populated")
 	}
@@ -62,21 +72,383 @@ func TestCloudWatchSNSAdapterTransform(t *testing.T) {
 
 func TestCloudWatchSNSAdapterTransformEnvelope(t *testing.T) {
 	envelope := json.RawMessage(`{"Records":[{"Sns":{"Message":` + sampleAlarmMessage + `}}]}`)
-	adapter := NewCloudWatchSNSAdapter("https://discord.example/cloudwatch")
-	payload, _, err := adapter.Transform(envelope)
+	adapter := NewCloudWatchSNSAdapter("https://discord.com/api/webhooks/200/cloudwatch")
+	payloads, _, err := adapter.Transform(envelope)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	payload := singlePayload(t, payloads)
 	if payload.Content == "" {
 		t.Fatalf("expected content to be populated")
 	}
 }
 
+func TestCloudWatchSNSAdapterTransformNotification(t *testing.T) {
+	message, _ := json.Marshal(sampleAlarmMessage)
+	notification := json.RawMessage(`{"Type":"Notification","MessageId":"abc","Message":` + string(message) + `}`)
+	payloads, _, err := NewCloudWatchSNSAdapter("https://discord.com/api/webhooks/200/cloudwatch").Transform(notification)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	payload := singlePayload(t, payloads)
+	if len(payload.Embeds) != 1 || payload.Embeds[0].Title != "CPUHigh" {
+		t.Fatalf("expected alarm to be decoded from SNS notification: %#v", payload.Embeds)
+	}
+}
+
+func TestCloudWatchSNSAdapterTransformMultipleRecords(t *testing.T) {
+	okAlarm := strings.Replace(sampleAlarmMessage, `"NewStateValue": "ALARM"`, `"NewStateValue": "OK"`, 1)
+	first, _ := json.Marshal(sampleAlarmMessage)
+	second, _ := json.Marshal(okAlarm)
+	envelope := json.RawMessage(`{"Records":[{"Sns":{"Message":` + string(first) + `}},{"Sns":{"Message":` + string(second) + `}}]}`)
+
+	payloads, eventMap, err := NewCloudWatchSNSAdapter("https://discord.com/api/webhooks/200/cloudwatch").Transform(envelope)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(payloads) != 2 {
+		t.Fatalf("expected one payload per record, got %d", len(payloads))
+	}
+	if payloads[0].Embeds[0].Color != 0xE74C3C || payloads[1].Embeds[0].Color != 0x2ECC71 {
+		t.Fatalf("expected records to keep their order")
+	}
+	records, ok := eventMap["Records"].([]any)
+	if !ok || len(records) != 2 {
+		t.Fatalf("expected event map to contain every record: %#v", eventMap)
+	}
+}
+
+func TestCloudWatchSNSAdapterTransformCombinedRecords(t *testing.T) {
+	message, _ := json.Marshal(sampleAlarmMessage)
+	record := `{"Sns":{"Message":` + string(message) + `}}`
+	envelope := json.RawMessage(`{"Records":[` + record + `,` + record + `,` + record + `]}`)
+
+	payloads, _, err := NewCloudWatchSNSAdapter("https://discord.com/api/webhooks/200/cloudwatch").WithCombinedRecords(true).Transform(envelope)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	payload := singlePayload(t, payloads)
+	if len(payload.Embeds) != 3 {
+		t.Fatalf("expected an embed per record, got %d", len(payload.Embeds))
+	}
+	if !strings.Contains(payload.Content, "3 CloudWatch alarms") {
+		t.Fatalf("unexpected summary: %s", payload.Content)
+	}
+}
+
+func TestCloudWatchSNSAdapterAttributes(t *testing.T) {
+	payloads, _, err := NewCloudWatchSNSAdapter("https://discord.com/api/webhooks/200/cloudwatch").Transform(json.RawMessage(sampleAlarmMessage))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{
+		domain.AttributeAlarmName:  "CPUHigh",
+		domain.AttributeNamespace:  "AWS/EC2",
+		domain.AttributeMetricName: "CPUUtilization",
+		domain.AttributeAccount:    "123456789012",
+		domain.AttributeRegion:     "us-east-1",
+		domain.AttributeState:      "ALARM",
+	}
+	for name, value := range want {
+		if got := singlePayload(t, payloads).Attributes[name]; got != value {
+			t.Fatalf("unexpected %s attribute: %q", name, got)
+		}
+	}
+
+	first, _ := json.Marshal(sampleAlarmMessage)
+	second, _ := json.Marshal(strings.Replace(sampleAlarmMessage, `"NewStateValue": "ALARM"`, `"NewStateValue": "OK"`, 1))
+	envelope := json.RawMessage(`{"Records":[{"Sns":{"Message":` + string(first) + `}},{"Sns":{"Message":` + string(second) + `}}]}`)
+	payloads, _, err = NewCloudWatchSNSAdapter("https://discord.com/api/webhooks/200/cloudwatch").WithCombinedRecords(true).Transform(envelope)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	combined := singlePayload(t, payloads).Attributes
+	if combined[domain.AttributeAlarmName] != "CPUHigh" {
+		t.Fatalf("expected shared attributes to be kept: %#v", combined)
+	}
+	if _, ok := combined[domain.AttributeState]; ok {
+		t.Fatalf("expected differing attributes to be dropped: %#v", combined)
+	}
+}
+
+func TestCloudWatchSNSAdapterTransformReportsRecordErrors(t *testing.T) {
+	message, _ := json.Marshal(sampleAlarmMessage)
+	envelope := json.RawMessage(`{"Records":[{"Sns":{"Message":"not json"}},{"Sns":{"Message":` + string(message) + `}}]}`)
+
+	payloads, _, err := NewCloudWatchSNSAdapter("https://discord.com/api/webhooks/200/cloudwatch").Transform(envelope)
+	if err == nil || !strings.Contains(err.Error(), "record 0") {
+		t.Fatalf("expected error for the first record, got %v", err)
+	}
+	if len(payloads) != 1 || payloads[0].Embeds[0].Title != "CPUHigh" {
+		t.Fatalf("expected valid records to still produce payloads: %#v", payloads)
+	}
+}
+
+func TestCloudWatchSNSAdapterAlarmThreads(t *testing.T) {
+	raw := json.RawMessage(sampleAlarmMessage)
+
+	payloads, _, err := NewCloudWatchSNSAdapter("https://discord.com/api/webhooks/200/cloudwatch").WithAlarmThreads(true, nil).Transform(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if payload := singlePayload(t, payloads); payload.ThreadName != "CPUHigh" || payload.ThreadID != "" || payload.ThreadKey != "arn:aws:cloudwatch:us-east-1:123456789012:alarm:CPUHigh" {
+		t.Fatalf("expected a forum post per alarm: %#v", payload)
+	}
+
+	threadIDs := map[string]string{"CPUHigh": "42"}
+	payloads, _, err = NewCloudWatchSNSAdapter("https://discord.com/api/webhooks/200/cloudwatch").WithAlarmThreads(true, threadIDs).Transform(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if payload := singlePayload(t, payloads); payload.ThreadID != "42" || payload.ThreadName != "" {
+		t.Fatalf("expected mapped thread to be used: %#v", payload)
+	}
+}
+
+func TestAlarmConsoleURL(t *testing.T) {
+	tests := []struct {
+		name  string
+		alarm cloudWatchAlarm
+		want  string
+	}{
+		{
+			name:  "region from arn",
+			alarm: cloudWatchAlarm{AlarmName: "CPU High", Region: "US East (N. Virginia)", AlarmArn: "arn:aws:cloudwatch:us-east-1:123456789012:alarm:CPU High"},
+			want:  "https://us-east-1.console.aws.amazon.com/cloudwatch/home?region=us-east-1#alarmsV2:alarm/CPU%20High",
+		},
+		{
+			name:  "region code without arn",
+			alarm: cloudWatchAlarm{AlarmName: "CPUHigh", Region: "ap-northeast-1"},
+			want:  "https://ap-northeast-1.console.aws.amazon.com/cloudwatch/home?region=ap-northeast-1#alarmsV2:alarm/CPUHigh",
+		},
+		{
+			name:  "china partition",
+			alarm: cloudWatchAlarm{AlarmName: "CPUHigh", AlarmArn: "arn:aws-cn:cloudwatch:cn-north-1:123456789012:alarm:CPUHigh"},
+			want:  "https://cn-north-1.console.amazonaws.cn/cloudwatch/home?region=cn-north-1#alarmsV2:alarm/CPUHigh",
+		},
+		{
+			name:  "display region only",
+			alarm: cloudWatchAlarm{AlarmName: "CPUHigh", Region: "US East (N. Virginia)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := alarmConsoleURL(tt.alarm); got != tt.want {
+				t.Fatalf("unexpected url: %s", got)
+			}
+		})
+	}
+}
+
+func TestBuildAlarmComponents(t *testing.T) {
+	var alarm cloudWatchAlarm
+	if err := json.Unmarshal([]byte(sampleAlarmMessage), &alarm); err != nil {
+		t.Fatalf("failed to decode sample: %v", err)
+	}
+	alarm.AlarmDescription = "CPU usage is high. Runbook: https://wiki.example.com/runbooks/cpu Dashboard=https://example.com/dash"
+
+	components := buildAlarmComponents(alarm, i18n.Default())
+	if len(components) != 1 || components[0].Type != domain.ComponentActionRow {
+		t.Fatalf("expected a single action row: %#v", components)
+	}
+	buttons := components[0].Components
+	want := []struct{ label, url string }{
+		{"Open alarm", "https://us-east-1.console.aws.amazon.com/cloudwatch/home?region=us-east-1#alarmsV2:alarm/CPUHigh"},
+		{"View metric", "https://us-east-1.console.aws.amazon.com/cloudwatch/home?region=us-east-1#metricsV2:graph=~(metrics~(~(~'AWS*2fEC2~'CPUUtilization~'InstanceId~'i-1234567890abcdef0))~region~'us-east-1)"},
+		{"Runbook", "https://wiki.example.com/runbooks/cpu"},
+		{"Dashboard", "https://example.com/dash"},
+	}
+	if len(buttons) != len(want) {
+		t.Fatalf("expected %d buttons, got %#v", len(want), buttons)
+	}
+	for i, w := range want {
+		if buttons[i].Style != domain.ButtonLink || buttons[i].Label != w.label || buttons[i].URL != w.url {
+			t.Fatalf("unexpected button %d: %#v", i, buttons[i])
+		}
+	}
+
+	if components := buildAlarmComponents(cloudWatchAlarm{AlarmName: "CPUHigh"}, i18n.Default()); components != nil {
+		t.Fatalf("expected no buttons without a region: %#v", components)
+	}
+
+	alarm.AlarmDescription = "Runbook: https://wiki.example.com/" + strings.Repeat("r", domain.MaxButtonURLLength)
+	for i := 0; i < 5; i++ {
+		alarm.Trigger.Dimensions = append(alarm.Trigger.Dimensions, cloudWatchDimension{Name: "ServiceName", Value: strings.Repeat("s", 80)})
+	}
+	buttons = buildAlarmComponents(alarm, i18n.Default())[0].Components
+	if len(buttons) != 1 || buttons[0].Label != "Open alarm" {
+		t.Fatalf("expected buttons with over-long URLs to be dropped: %#v", buttons)
+	}
+	payload := domain.NotificationPayload{WebhookURL: "https://discord.com/api/webhooks/200/cloudwatch", Content: "alarm", Components: buildAlarmComponents(alarm, i18n.Default())}
+	if err := payload.Validate(); err != nil {
+		t.Fatalf("expected the remaining buttons to be valid: %v", err)
+	}
+}
+
+func TestCloudWatchSNSAdapterSilentStates(t *testing.T) {
+	adapter := NewCloudWatchSNSAdapter("https://discord.com/api/webhooks/200/cloudwatch").WithSilentStates([]string{" ok "})
+
+	payloads, _, err := adapter.Transform(json.RawMessage(sampleAlarmMessage))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if flags := singlePayload(t, payloads).Flags; flags != 0 {
+		t.Fatalf("expected ALARM to notify, got flags %d", flags)
+	}
+
+	payloads, _, err = adapter.Transform(json.RawMessage(`{"AlarmName":"CPUHigh","NewStateValue":"OK"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if flags := singlePayload(t, payloads).Flags; flags != domain.FlagSuppressNotifications {
+		t.Fatalf("expected OK to be silent, got flags %d", flags)
+	}
+}
+
+func TestCloudWatchSNSAdapterCorrelation(t *testing.T) {
+	payloads, _, err := NewCloudWatchSNSAdapter("https://discord.com/api/webhooks/200/cloudwatch").Transform(json.RawMessage(sampleAlarmMessage))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	correlation := singlePayload(t, payloads).Correlation
+	if correlation == nil || correlation.Kind != domain.CorrelationOpen {
+		t.Fatalf("expected ALARM to open a correlation: %#v", correlation)
+	}
+	if correlation.Key != "arn:aws:cloudwatch:us-east-1:123456789012:alarm:CPUHigh" {
+		t.Fatalf("unexpected correlation key: %s", correlation.Key)
+	}
+	if !correlation.OccurredAt.Equal(time.Date(2024, 1, 2, 3, 4, 5, 678000000, time.UTC)) {
+		t.Fatalf("unexpected occurred at: %s", correlation.OccurredAt)
+	}
+
+	raw := json.RawMessage(`{"AlarmName":"CPUHigh","NewStateValue":"OK","StateChangeTime":"2024-01-02T03:34:05.678+0000"}`)
+	payloads, _, err = NewCloudWatchSNSAdapter("https://discord.com/api/webhooks/200/cloudwatch").Transform(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	correlation = singlePayload(t, payloads).Correlation
+	if correlation == nil || correlation.Kind != domain.CorrelationResolve || correlation.Key != "CPUHigh" {
+		t.Fatalf("expected OK to resolve by alarm name: %#v", correlation)
+	}
+	if !correlation.OccurredAt.Equal(time.Date(2024, 1, 2, 3, 34, 5, 678000000, time.UTC)) {
+		t.Fatalf("unexpected occurred at: %s", correlation.OccurredAt)
+	}
+
+	raw = json.RawMessage(`{"AlarmName":"CPUHigh","NewStateValue":"INSUFFICIENT_DATA"}`)
+	payloads, _, err = NewCloudWatchSNSAdapter("https://discord.com/api/webhooks/200/cloudwatch").Transform(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if correlation := singlePayload(t, payloads).Correlation; correlation != nil {
+		t.Fatalf("expected no correlation for INSUFFICIENT_DATA: %#v", correlation)
+	}
+}
+
+func TestCloudWatchSNSAdapterChangedAt(t *testing.T) {
+	raw := json.RawMessage(`{"AlarmName":"CPUHigh","NewStateValue":"ALARM","StateChangeTime":"2024-01-02T03:34:05.678+0000"}`)
+	payloads, _, err := NewCloudWatchSNSAdapter("https://discord.com/api/webhooks/200/cloudwatch").
+		WithDisplayLocation(time.FixedZone("JST", 9*60*60)).
+		Transform(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	embed := singlePayload(t, payloads).Embeds[0]
+	if embed.Timestamp != "2024-01-02T03:34:05.678Z" {
+		t.Fatalf("expected an RFC3339 timestamp: %s", embed.Timestamp)
+	}
+	field := embed.Fields[len(embed.Fields)-1]
+	if field.Name != "Changed At" || field.Value != "2024-01-02 12:34:05 JST (<t:1704166445:R>)" {
+		t.Fatalf("unexpected changed at field: %#v", field)
+	}
+
+	raw = json.RawMessage(`{"AlarmName":"CPUHigh","NewStateValue":"ALARM","StateChangeTime":"yesterday"}`)
+	payloads, _, err = NewCloudWatchSNSAdapter("https://discord.com/api/webhooks/200/cloudwatch").Transform(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	embed = singlePayload(t, payloads).Embeds[0]
+	if embed.Timestamp != "" {
+		t.Fatalf("expected an unparseable time to be dropped: %s", embed.Timestamp)
+	}
+	for _, field := range embed.Fields {
+		if field.Name == "Changed At" {
+			t.Fatalf("unexpected changed at field: %#v", field)
+		}
+	}
+}
+
+func TestFormatChangedAtDefaultsToUTC(t *testing.T) {
+	changedAt := time.Date(2024, 1, 2, 12, 34, 5, 0, time.FixedZone("", 9*60*60))
+	if got := formatChangedAt(changedAt, nil); got != "2024-01-02 03:34:05 UTC (<t:1704166445:R>)" {
+		t.Fatalf("unexpected changed at: %s", got)
+	}
+}
+
 func TestCloudWatchSNSAdapterErrors(t *testing.T) {
 	if _, _, err := NewCloudWatchSNSAdapter("").Transform(json.RawMessage(`{}`)); err == nil {
 		t.Fatal("expected error when webhook missing")
 	}
-	if _, _, err := NewCloudWatchSNSAdapter("https://hook").Transform(json.RawMessage(`""`)); err == nil {
+	if _, _, err := NewCloudWatchSNSAdapter("https://discord.com/api/webhooks/100/hook").Transform(json.RawMessage(`""`)); err == nil {
 		t.Fatal("expected error for empty message")
 	}
 }
+
+func TestCloudWatchSNSAdapterTemplates(t *testing.T) {
+	set, err := templates.Parse([]byte(`{"cloudwatch": {"content": "{{.AlarmName}} -> {{.NewStateValue}}", "fields": [{"name": "Instance", "value": "{{jsonPath \"Trigger.Dimensions.0.value\" .}}"}]}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	payloads, _, err := NewCloudWatchSNSAdapter("https://discord.com/api/webhooks/200/cloudwatch").
+		WithTemplate(set.Lookup("cloudwatch")).
+		Transform(json.RawMessage(sampleAlarmMessage))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	payload := singlePayload(t, payloads)
+	if payload.Content != "CPUHigh -> ALARM" {
+		t.Fatalf("unexpected content: %q", payload.Content)
+	}
+	embed := payload.Embeds[0]
+	if embed.Title != "CPUHigh" || embed.Color != 0xE74C3C {
+		t.Fatalf("expected untemplated parts to keep the default rendering: %#v", embed)
+	}
+	if len(embed.Fields) != 1 || embed.Fields[0].Value != "i-1234567890abcdef0" {
+		t.Fatalf("unexpected fields: %#v", embed.Fields)
+	}
+}
+
+func TestCloudWatchSNSAdapterLocale(t *testing.T) {
+	ja, _ := i18n.Lookup("ja")
+	var resolved map[string]string
+	locale := func(attributes map[string]string, _ map[string]any) i18n.Catalog {
+		resolved = attributes
+		return ja
+	}
+	payloads, _, err := NewCloudWatchSNSAdapter("https://discord.com/api/webhooks/200/cloudwatch").
+		WithLocale(locale).
+		Transform(json.RawMessage(sampleAlarmMessage))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resolved[domain.AttributeAlarmName] != "CPUHigh" {
+		t.Fatalf("expected the resolver to receive alarm attributes: %#v", resolved)
+	}
+
+	payload := singlePayload(t, payloads)
+	if payload.Content != ":rotating_light: CloudWatch アラーム「CPUHigh」の状態: アラーム" {
+		t.Fatalf("unexpected content: %q", payload.Content)
+	}
+	fields := payload.Embeds[0].Fields
+	if fields[2].Name != "変更前の状態" || fields[2].Value != "正常" || fields[3].Value != "アラーム" {
+		t.Fatalf("unexpected fields: %#v", fields)
+	}
+	if !strings.Contains(fields[5].Value, "期間: 60 秒") {
+		t.Fatalf("unexpected trigger: %q", fields[5].Value)
+	}
+	if payload.Components[0].Components[0].Label != "アラームを開く" {
+		t.Fatalf("unexpected button: %#v", payload.Components[0].Components[0])
+	}
+	if payload.Attributes[domain.AttributeLocale] != "ja" {
+		t.Fatalf("expected locale attribute: %#v", payload.Attributes)
+	}
+}
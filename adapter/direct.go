@@ -2,6 +2,7 @@ package adapter
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -17,29 +18,59 @@ func NewDirectAdapter() DirectAdapter {
 	return DirectAdapter{}
 }
 
-func (DirectAdapter) Transform(event json.RawMessage) (domain.NotificationPayload, map[string]any, error) {
+func (DirectAdapter) Transform(event json.RawMessage) ([]domain.NotificationPayload, map[string]any, error) {
 	eventMap, err := normaliseEvent(event)
 	if err != nil {
-		return domain.NotificationPayload{}, nil, err
+		return nil, nil, err
 	}
 
-	webhookURL, err := extractWebhookURL(eventMap)
+	webhookURLs, err := extractWebhookURLs(eventMap)
 	if err != nil {
+		return nil, eventMap, err
+	}
+	webhookURL, err := extractWebhookURL(eventMap)
+	if err != nil && len(webhookURLs) == 0 {
 		fallback := strings.TrimSpace(os.Getenv("WEBHOOK_URL"))
 		if fallback == "" {
-			return domain.NotificationPayload{}, eventMap, err
+			return nil, eventMap, err
 		}
 		webhookURL = fallback
 	}
 
+	action, err := parseAction(eventMap["action"])
+	if err != nil {
+		return nil, eventMap, err
+	}
+
+	var attachments []domain.Attachment
+	if raw, ok := eventMap["attachments"]; ok {
+		attachments, err = parseAttachments(raw)
+		if err != nil {
+			return nil, eventMap, err
+		}
+	}
+
 	content := extractFirstNonEmpty(eventMap, "content", "message")
-	if content == "" {
-		return domain.NotificationPayload{}, eventMap, errors.New("event must contain a 'content' or 'message' field")
+	if content == "" && len(attachments) == 0 && action != domain.ActionDelete {
+		return nil, eventMap, errors.New("event must contain a 'content', 'message' or 'attachments' field")
 	}
 
 	payload := domain.NotificationPayload{
-		WebhookURL: webhookURL,
-		Content:    content,
+		Action:      action,
+		WebhookURL:  webhookURL,
+		WebhookURLs: webhookURLs,
+		Content:     content,
+		Attachments: attachments,
+	}
+
+	if action == domain.ActionEdit || action == domain.ActionDelete {
+		payload.MessageID = extractString(eventMap["message_id"])
+		if payload.MessageID == "" {
+			return nil, eventMap, fmt.Errorf("event must contain a 'message_id' to %s a message", action)
+		}
+		if len(payload.Destinations()) > 1 {
+			return nil, eventMap, fmt.Errorf("cannot %s a message on more than one webhook", action)
+		}
 	}
 
 	if username := extractString(eventMap["username"]); username != "" {
@@ -48,24 +79,47 @@ func (DirectAdapter) Transform(event json.RawMessage) (domain.NotificationPayloa
 	if avatar := extractString(eventMap["avatar_url"]); avatar != "" {
 		payload.AvatarURL = avatar
 	}
+	if threadID := extractString(eventMap["thread_id"]); threadID != "" {
+		payload.ThreadID = threadID
+	}
+	if threadName := extractString(eventMap["thread_name"]); threadName != "" {
+		payload.ThreadName = threadName
+	}
+	if wait, ok := eventMap["wait"].(bool); ok {
+		payload.Wait = wait
+	}
+	if silent, ok := eventMap["silent"].(bool); ok && silent {
+		payload.Flags |= domain.FlagSuppressNotifications
+	}
+	if suppress, ok := eventMap["suppress_embeds"].(bool); ok && suppress {
+		payload.Flags |= domain.FlagSuppressEmbeds
+	}
 
 	if embeds, ok := eventMap["embeds"]; ok {
 		parsed, err := parseEmbeds(embeds)
 		if err != nil {
-			return domain.NotificationPayload{}, eventMap, err
+			return nil, eventMap, err
 		}
 		payload.Embeds = parsed
 	}
 
+	if raw, ok := eventMap["components"]; ok {
+		components, err := parseComponents(raw)
+		if err != nil {
+			return nil, eventMap, err
+		}
+		payload.Components = components
+	}
+
 	if allowed, ok := eventMap["allowed_mentions"]; ok {
 		mentions, err := parseAllowedMentions(allowed)
 		if err != nil {
-			return domain.NotificationPayload{}, eventMap, err
+			return nil, eventMap, err
 		}
 		payload.AllowedMentions = mentions
 	}
 
-	return payload, eventMap, nil
+	return []domain.NotificationPayload{payload}, eventMap, nil
 }
 
 func normaliseEvent(raw json.RawMessage) (map[string]any, error) {
@@ -98,6 +152,16 @@ func normaliseEvent(raw json.RawMessage) (map[string]any, error) {
 	return nil, errors.New("event must be an object or JSON string")
 }
 
+func parseAction(raw any) (domain.Action, error) {
+	action := domain.Action(strings.ToLower(extractString(raw)))
+	switch action {
+	case "", domain.ActionSend, domain.ActionEdit, domain.ActionDelete:
+		return action, nil
+	default:
+		return "", fmt.Errorf("unsupported action: %s", action)
+	}
+}
+
 func extractWebhookURL(event map[string]any) (string, error) {
 	for _, key := range []string{"webhookURL", "webhook_url"} {
 		if value, ok := event[key]; ok {
@@ -110,6 +174,31 @@ func extractWebhookURL(event map[string]any) (string, error) {
 	return "", errors.New("event must contain a 'webhookURL' or 'webhook_url'")
 }
 
+func extractWebhookURLs(event map[string]any) ([]string, error) {
+	for _, key := range []string{"webhookURLs", "webhook_urls"} {
+		raw, ok := event[key]
+		if !ok {
+			continue
+		}
+		items, ok := raw.([]any)
+		if !ok {
+			return nil, fmt.Errorf("'%s' must be an array of strings", key)
+		}
+		var urls []string
+		for i, item := range items {
+			value, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s[%d] must be a string", key, i)
+			}
+			if value = strings.TrimSpace(value); value != "" {
+				urls = append(urls, value)
+			}
+		}
+		return urls, nil
+	}
+	return nil, nil
+}
+
 func extractFirstNonEmpty(event map[string]any, keys ...string) string {
 	for _, key := range keys {
 		if value, ok := event[key]; ok {
@@ -172,3 +261,70 @@ func parseAllowedMentions(raw any) (*domain.AllowedMentions, error) {
 	}
 	return &mentions, nil
 }
+
+func parseComponents(raw any) ([]domain.Component, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	marshalled, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal components: %w", err)
+	}
+	var components []domain.Component
+	if err := json.Unmarshal(marshalled, &components); err != nil {
+		return nil, fmt.Errorf("components must be an array of action rows: %w", err)
+	}
+	return components, nil
+}
+
+type directAttachment struct {
+	Filename    string `json:"filename"`
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Content     string `json:"content"`
+	Description string `json:"description"`
+}
+
+func parseAttachments(raw any) ([]domain.Attachment, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	marshalled, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal attachments: %w", err)
+	}
+	var entries []directAttachment
+	if err := json.Unmarshal(marshalled, &entries); err != nil {
+		return nil, fmt.Errorf("attachments must be an array: %w", err)
+	}
+
+	attachments := make([]domain.Attachment, 0, len(entries))
+	for i, entry := range entries {
+		name := strings.TrimSpace(entry.Filename)
+		if name == "" {
+			name = strings.TrimSpace(entry.Name)
+		}
+		if name == "" {
+			return nil, fmt.Errorf("attachments[%d] must contain a 'filename'", i)
+		}
+		data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(entry.Content))
+		if err != nil {
+			return nil, fmt.Errorf("attachments[%d].content must be base64: %w", i, err)
+		}
+		attachments = append(attachments, domain.Attachment{
+			Name:        name,
+			ContentType: strings.TrimSpace(entry.ContentType),
+			Data:        data,
+			Description: entry.Description,
+		})
+	}
+	return attachments, nil
+}
+
+func detectDirect(event json.RawMessage) bool {
+	eventMap, err := normaliseEvent(event)
+	if err != nil {
+		return false
+	}
+	return extractFirstNonEmpty(eventMap, "content", "message") != ""
+}
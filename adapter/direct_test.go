@@ -3,11 +3,21 @@ package adapter
 import (
 	"encoding/json"
 	"testing"
+
+	"lambda-to-discord/domain"
 )
 
+func singlePayload(t *testing.T, payloads []domain.NotificationPayload) domain.NotificationPayload {
+	t.Helper()
+	if len(payloads) != 1 {
+		t.Fatalf("expected a single payload, got %d", len(payloads))
+	}
+	return payloads[0]
+}
+
 func TestDirectAdapterTransformSuccess(t *testing.T) {
 	raw := json.RawMessage(`{
-                "webhookURL": "https://discord.example/hook",
+                "webhookURL": "https://discord.com/api/webhooks/100/hook",
                 "content": "hello",
                 "username": "bot",
                 "avatar_url": "https://example.com/avatar.png",
@@ -15,11 +25,12 @@ func TestDirectAdapterTransformSuccess(t *testing.T) {
                 "allowed_mentions": {"parse": []}
         }`)
 
-	payload, eventMap, err := NewDirectAdapter().Transform(raw)
+	payloads, eventMap, err := NewDirectAdapter().Transform(raw)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if payload.WebhookURL != "https://discord.example/hook" {
+	payload := singlePayload(t, payloads)
+	if payload.WebhookURL != "https://discord.com/api/webhooks/100/hook" {
 		t.Fatalf("unexpected webhook: %s", payload.WebhookURL)
 	}
 	if payload.Content != "hello" {
@@ -40,25 +51,27 @@ func TestDirectAdapterTransformSuccess(t *testing.T) {
 }
 
 func TestDirectAdapterTransformAllowsMessageFallback(t *testing.T) {
-	raw := json.RawMessage(`{"webhook_url": "https://discord.example/hook", "message": "fallback"}`)
-	payload, _, err := NewDirectAdapter().Transform(raw)
+	raw := json.RawMessage(`{"webhook_url": "https://discord.com/api/webhooks/100/hook", "message": "fallback"}`)
+	payloads, _, err := NewDirectAdapter().Transform(raw)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	payload := singlePayload(t, payloads)
 	if payload.Content != "fallback" {
 		t.Fatalf("expected fallback content, got %s", payload.Content)
 	}
 }
 
 func TestDirectAdapterTransformUsesEnvFallback(t *testing.T) {
-	t.Setenv("WEBHOOK_URL", "https://discord.example/fallback")
+	t.Setenv("WEBHOOK_URL", "https://discord.com/api/webhooks/800/fallback")
 
 	raw := json.RawMessage(`{"content": "env"}`)
-	payload, _, err := NewDirectAdapter().Transform(raw)
+	payloads, _, err := NewDirectAdapter().Transform(raw)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if payload.WebhookURL != "https://discord.example/fallback" {
+	payload := singlePayload(t, payloads)
+	if payload.WebhookURL != "https://discord.com/api/webhooks/800/fallback" {
 		t.Fatalf("expected webhook to fall back to env var, got %s", payload.WebhookURL)
 	}
 	if payload.Content != "env" {
@@ -73,14 +86,14 @@ func TestDirectAdapterTransformError(t *testing.T) {
 	if _, _, err := NewDirectAdapter().Transform(json.RawMessage(`123`)); err == nil {
 		t.Fatal("expected error for invalid payload")
 	}
-	if _, _, err := NewDirectAdapter().Transform(json.RawMessage(`{"webhookURL":"https://hook"}`)); err == nil {
+	if _, _, err := NewDirectAdapter().Transform(json.RawMessage(`{"webhookURL":"https://discord.com/api/webhooks/100/hook"}`)); err == nil {
 		t.Fatal("expected error when content missing")
 	}
 }
 
 func TestDirectAdapterTransformAllowedMentions(t *testing.T) {
 	raw := json.RawMessage(`{
-                "webhookURL": "https://discord.example/hook",
+                "webhookURL": "https://discord.com/api/webhooks/100/hook",
                 "content": "hi",
                 "allowed_mentions": {
                         "parse": ["users"],
@@ -88,10 +101,11 @@ func TestDirectAdapterTransformAllowedMentions(t *testing.T) {
                         "replied_user": true
                 }
         }`)
-	payload, _, err := NewDirectAdapter().Transform(raw)
+	payloads, _, err := NewDirectAdapter().Transform(raw)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	payload := singlePayload(t, payloads)
 	if payload.AllowedMentions == nil {
 		t.Fatalf("expected allowed mentions to be set")
 	}
@@ -102,3 +116,185 @@ func TestDirectAdapterTransformAllowedMentions(t *testing.T) {
 		t.Fatalf("unexpected allowed mentions users: %#v", payload.AllowedMentions.Users)
 	}
 }
+
+func TestDirectAdapterTransformThreadAndWait(t *testing.T) {
+	raw := json.RawMessage(`{"webhookURL": "https://discord.com/api/webhooks/100/hook", "content": "hi", "thread_id": "1234567890", "thread_name": "deploys", "wait": true}`)
+	payloads, _, err := NewDirectAdapter().Transform(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	payload := singlePayload(t, payloads)
+	if payload.ThreadID != "1234567890" || payload.ThreadName != "deploys" {
+		t.Fatalf("unexpected thread settings: %#v", payload)
+	}
+	if !payload.Wait {
+		t.Fatalf("expected wait to be requested")
+	}
+}
+
+func TestDirectAdapterTransformActions(t *testing.T) {
+	raw := json.RawMessage(`{"webhookURL": "https://discord.com/api/webhooks/100/hook", "content": "succeeded", "action": "edit", "message_id": "111"}`)
+	payloads, _, err := NewDirectAdapter().Transform(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if payload := singlePayload(t, payloads); payload.Action != domain.ActionEdit || payload.MessageID != "111" {
+		t.Fatalf("unexpected edit payload: %#v", payload)
+	}
+
+	raw = json.RawMessage(`{"webhookURL": "https://discord.com/api/webhooks/100/hook", "action": "DELETE", "message_id": "111"}`)
+	payloads, _, err = NewDirectAdapter().Transform(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if payload := singlePayload(t, payloads); payload.Action != domain.ActionDelete || payload.MessageID != "111" {
+		t.Fatalf("unexpected delete payload: %#v", payload)
+	}
+
+	if _, _, err := NewDirectAdapter().Transform(json.RawMessage(`{"webhookURL": "https://discord.com/api/webhooks/100/hook", "content": "x", "action": "edit"}`)); err == nil {
+		t.Fatal("expected error when message_id missing")
+	}
+	if _, _, err := NewDirectAdapter().Transform(json.RawMessage(`{"webhookURL": "https://discord.com/api/webhooks/100/hook", "content": "x", "action": "pin"}`)); err == nil {
+		t.Fatal("expected error for unsupported action")
+	}
+}
+
+func TestDirectAdapterTransformWebhookURLs(t *testing.T) {
+	raw := json.RawMessage(`{"webhookURLs": ["https://discord.com/api/webhooks/1/team", " https://discord.com/api/webhooks/2/incidents "], "content": "deployed"}`)
+	payloads, _, err := NewDirectAdapter().Transform(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	destinations := singlePayload(t, payloads).Destinations()
+	if len(destinations) != 2 || destinations[1] != "https://discord.com/api/webhooks/2/incidents" {
+		t.Fatalf("unexpected destinations: %#v", destinations)
+	}
+
+	for _, event := range []string{
+		`{"webhook_urls": "https://discord.com/api/webhooks/1/team", "content": "x"}`,
+		`{"webhook_urls": [1], "content": "x"}`,
+		`{"webhookURLs": ["https://discord.com/api/webhooks/1/team", "https://discord.com/api/webhooks/2/incidents"], "action": "delete", "message_id": "111"}`,
+	} {
+		if _, _, err := NewDirectAdapter().Transform(json.RawMessage(event)); err == nil {
+			t.Fatalf("expected error for %s", event)
+		}
+	}
+}
+
+func TestDirectAdapterTransformAttachments(t *testing.T) {
+	raw := json.RawMessage(`{
+                "webhookURL": "https://discord.com/api/webhooks/100/hook",
+                "attachments": [
+                        {"filename": "report.csv", "content_type": "text/csv", "content": "YSxiCjEsMgo=", "description": "daily report"}
+                ]
+        }`)
+
+	payloads, _, err := NewDirectAdapter().Transform(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	payload := singlePayload(t, payloads)
+	if len(payload.Attachments) != 1 {
+		t.Fatalf("expected one attachment, got %d", len(payload.Attachments))
+	}
+	attachment := payload.Attachments[0]
+	if attachment.Name != "report.csv" || attachment.ContentType != "text/csv" || attachment.Description != "daily report" {
+		t.Fatalf("unexpected attachment: %#v", attachment)
+	}
+	if string(attachment.Data) != "a,b\n1,2\n" {
+		t.Fatalf("unexpected attachment data: %q", attachment.Data)
+	}
+
+	invalid := []string{
+		`{"webhookURL": "https://discord.com/api/webhooks/100/hook", "attachments": [{"filename": "a.txt", "content": "not base64!"}]}`,
+		`{"webhookURL": "https://discord.com/api/webhooks/100/hook", "attachments": [{"content": "YQ=="}]}`,
+		`{"webhookURL": "https://discord.com/api/webhooks/100/hook", "attachments": {"filename": "a.txt"}}`,
+	}
+	for _, event := range invalid {
+		if _, _, err := NewDirectAdapter().Transform(json.RawMessage(event)); err == nil {
+			t.Fatalf("expected error for %s", event)
+		}
+	}
+}
+
+func TestDirectAdapterTransformFullEmbed(t *testing.T) {
+	raw := json.RawMessage(`{
+                "webhookURL": "https://discord.com/api/webhooks/100/hook",
+                "content": "hello",
+                "embeds": [{
+                        "title": "Deploy",
+                        "url": "https://example.com/deploys/1",
+                        "author": {"name": "ci", "url": "https://example.com", "icon_url": "https://example.com/ci.png"},
+                        "image": {"url": "https://example.com/graph.png"},
+                        "thumbnail": {"url": "https://example.com/thumb.png"},
+                        "provider": {"name": "Example"},
+                        "footer": {"text": "build 42", "icon_url": "https://example.com/footer.png"}
+                }]
+        }`)
+
+	payloads, _, err := NewDirectAdapter().Transform(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	embed := singlePayload(t, payloads).Embeds[0]
+	if embed.URL != "https://example.com/deploys/1" {
+		t.Fatalf("unexpected url: %s", embed.URL)
+	}
+	if embed.Author == nil || embed.Author.Name != "ci" || embed.Author.IconURL != "https://example.com/ci.png" {
+		t.Fatalf("unexpected author: %#v", embed.Author)
+	}
+	if embed.Image == nil || embed.Image.URL != "https://example.com/graph.png" || embed.Thumbnail == nil || embed.Thumbnail.URL != "https://example.com/thumb.png" {
+		t.Fatalf("unexpected media: %#v %#v", embed.Image, embed.Thumbnail)
+	}
+	if embed.Provider == nil || embed.Provider.Name != "Example" {
+		t.Fatalf("unexpected provider: %#v", embed.Provider)
+	}
+	if embed.Footer == nil || embed.Footer.IconURL != "https://example.com/footer.png" {
+		t.Fatalf("unexpected footer: %#v", embed.Footer)
+	}
+
+	encoded, err := json.Marshal(embed)
+	if err != nil {
+		t.Fatalf("failed to encode embed: %v", err)
+	}
+	want := `{"title":"Deploy","url":"https://example.com/deploys/1","footer":{"text":"build 42","icon_url":"https://example.com/footer.png"},"image":{"url":"https://example.com/graph.png"},"thumbnail":{"url":"https://example.com/thumb.png"},"provider":{"name":"Example"},"author":{"name":"ci","url":"https://example.com","icon_url":"https://example.com/ci.png"}}`
+	if string(encoded) != want {
+		t.Fatalf("unexpected round trip:\n%s\n%s", encoded, want)
+	}
+}
+
+func TestDirectAdapterTransformComponents(t *testing.T) {
+	raw := json.RawMessage(`{
+                "webhookURL": "https://discord.com/api/webhooks/100/hook",
+                "content": "deployed",
+                "components": [{"type": 1, "components": [{"type": 2, "style": 5, "label": "Open", "url": "https://example.com"}]}]
+        }`)
+
+	payloads, _, err := NewDirectAdapter().Transform(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	components := singlePayload(t, payloads).Components
+	if len(components) != 1 || len(components[0].Components) != 1 {
+		t.Fatalf("unexpected components: %#v", components)
+	}
+	if button := components[0].Components[0]; button.Style != domain.ButtonLink || button.URL != "https://example.com" {
+		t.Fatalf("unexpected button: %#v", button)
+	}
+
+	if _, _, err := NewDirectAdapter().Transform(json.RawMessage(`{"webhookURL": "https://discord.com/api/webhooks/100/hook", "content": "x", "components": {"type": 1}}`)); err == nil {
+		t.Fatal("expected error for non-array components")
+	}
+}
+
+func TestDirectAdapterTransformFlags(t *testing.T) {
+	raw := json.RawMessage(`{"webhookURL": "https://discord.com/api/webhooks/100/hook", "content": "https://example.com", "silent": true, "suppress_embeds": true}`)
+
+	payloads, _, err := NewDirectAdapter().Transform(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if flags := singlePayload(t, payloads).Flags; flags != domain.FlagSuppressNotifications|domain.FlagSuppressEmbeds {
+		t.Fatalf("unexpected flags: %d", flags)
+	}
+}
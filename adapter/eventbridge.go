@@ -0,0 +1,243 @@
+package adapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"lambda-to-discord/domain"
+	"lambda-to-discord/i18n"
+	"lambda-to-discord/templates"
+)
+
+type EventBridgeEvent struct {
+	Version    string          `json:"version"`
+	ID         string          `json:"id"`
+	Source     string          `json:"source"`
+	DetailType string          `json:"detail-type"`
+	Account    string          `json:"account"`
+	Region     string          `json:"region"`
+	Time       string          `json:"time"`
+	Resources  []string        `json:"resources"`
+	Detail     json.RawMessage `json:"detail"`
+}
+
+type EventBridgeRenderer func(event EventBridgeEvent, catalog i18n.Catalog) (domain.Embed, error)
+
+var (
+	eventBridgeRenderersMu sync.RWMutex
+	eventBridgeRenderers   = map[string]EventBridgeRenderer{
+		"aws.cloudwatch": renderCloudWatchAlarmStateChange,
+	}
+)
+
+func RegisterEventBridgeRenderer(source string, renderer EventBridgeRenderer) {
+	eventBridgeRenderersMu.Lock()
+	defer eventBridgeRenderersMu.Unlock()
+	if renderer == nil {
+		delete(eventBridgeRenderers, source)
+		return
+	}
+	eventBridgeRenderers[source] = renderer
+}
+
+func lookupEventBridgeRenderer(source string) (EventBridgeRenderer, bool) {
+	eventBridgeRenderersMu.RLock()
+	defer eventBridgeRenderersMu.RUnlock()
+	renderer, ok := eventBridgeRenderers[source]
+	return renderer, ok
+}
+
+type EventBridgeAdapter struct {
+	webhookURL string
+	template   *templates.Message
+	locale     LocaleResolver
+}
+
+func NewEventBridgeAdapter(webhookURL string) EventBridgeAdapter {
+	return EventBridgeAdapter{webhookURL: strings.TrimSpace(webhookURL)}
+}
+
+func (a EventBridgeAdapter) WithTemplate(template *templates.Message) EventBridgeAdapter {
+	a.template = template
+	return a
+}
+
+func (a EventBridgeAdapter) WithLocale(locale LocaleResolver) EventBridgeAdapter {
+	a.locale = locale
+	return a
+}
+
+func (a EventBridgeAdapter) Transform(event json.RawMessage) ([]domain.NotificationPayload, map[string]any, error) {
+	if strings.TrimSpace(a.webhookURL) == "" {
+		return nil, nil, errors.New("eventbridge adapter requires webhook url")
+	}
+
+	decoded, eventMap, err := decodeEventBridgeEvent(event)
+	if err != nil {
+		return nil, eventMap, err
+	}
+
+	attrs := attributes(
+		domain.AttributeSource, decoded.Source,
+		domain.AttributeDetailType, decoded.DetailType,
+		domain.AttributeAccount, decoded.Account,
+		domain.AttributeRegion, decoded.Region,
+	)
+	for name, value := range alarmStateChangeAttributes(decoded) {
+		attrs[name] = value
+	}
+	catalog := a.locale.catalog(attrs, eventMap)
+	attrs[domain.AttributeLocale] = catalog.Language()
+
+	renderer, ok := lookupEventBridgeRenderer(decoded.Source)
+	if !ok {
+		renderer = renderGenericEventBridgeEvent
+	}
+	embed, err := renderer(decoded, catalog)
+	if err != nil {
+		return nil, eventMap, fmt.Errorf("failed to render %s event: %w", decoded.Source, err)
+	}
+	if embed.Timestamp == "" {
+		embed.Timestamp = decoded.Time
+	}
+
+	payload := domain.NotificationPayload{
+		WebhookURL:      a.webhookURL,
+		Content:         a.template.Content(eventMap, catalog.T(i18n.EventReceived, decoded.DetailType, decoded.Source)),
+		Embeds:          []domain.Embed{a.template.Embed(embed, eventMap)},
+		AllowedMentions: domain.NoMentions(),
+		Attributes:      attrs,
+	}
+
+	return []domain.NotificationPayload{payload}, eventMap, nil
+}
+
+func decodeEventBridgeEvent(raw json.RawMessage) (EventBridgeEvent, map[string]any, error) {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 {
+		return EventBridgeEvent{}, nil, errors.New("eventbridge event is empty")
+	}
+
+	var asString string
+	if err := json.Unmarshal(trimmed, &asString); err == nil {
+		trimmed = bytes.TrimSpace([]byte(asString))
+	}
+
+	var event EventBridgeEvent
+	if err := json.Unmarshal(trimmed, &event); err != nil {
+		return EventBridgeEvent{}, nil, fmt.Errorf("failed to decode eventbridge event: %w", err)
+	}
+
+	var eventMap map[string]any
+	if err := json.Unmarshal(trimmed, &eventMap); err != nil {
+		eventMap = map[string]any{"raw": string(trimmed)}
+	}
+
+	if strings.TrimSpace(event.Source) == "" || strings.TrimSpace(event.DetailType) == "" {
+		return EventBridgeEvent{}, eventMap, errors.New("eventbridge event must contain 'source' and 'detail-type'")
+	}
+
+	return event, eventMap, nil
+}
+
+func renderGenericEventBridgeEvent(event EventBridgeEvent, catalog i18n.Catalog) (domain.Embed, error) {
+	embed := domain.Embed{
+		Title:       event.DetailType,
+		Description: formatEventBridgeDetail(event.Detail),
+	}
+
+	appendField := func(name, value string, inline bool) {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return
+		}
+		embed.Fields = append(embed.Fields, domain.EmbedField{Name: name, Value: value, Inline: inline})
+	}
+	appendField(catalog.T(i18n.FieldSource), event.Source, true)
+	appendField(catalog.T(i18n.FieldAccount), event.Account, true)
+	appendField(catalog.T(i18n.FieldRegion), event.Region, true)
+	appendField(catalog.T(i18n.FieldTime), event.Time, true)
+	appendField(catalog.T(i18n.FieldResources), strings.Join(event.Resources, "\n"), false)
+
+	return embed, nil
+}
+
+func formatEventBridgeDetail(detail json.RawMessage) string {
+	trimmed := bytes.TrimSpace(detail)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("{}")) || bytes.Equal(trimmed, []byte("null")) {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, trimmed, "", "  "); err != nil {
+		return fmt.Sprintf("```\n%s\n```", string(trimmed))
+	}
+	return fmt.Sprintf("```json\n%s\n```", buf.String())
+}
+
+const alarmStateChangeDetailType = "CloudWatch Alarm State Change"
+
+type cloudWatchAlarmStateChange struct {
+	AlarmName string `json:"alarmName"`
+	State     struct {
+		Value  string `json:"value"`
+		Reason string `json:"reason"`
+	} `json:"state"`
+	PreviousState struct {
+		Value string `json:"value"`
+	} `json:"previousState"`
+}
+
+func renderCloudWatchAlarmStateChange(event EventBridgeEvent, catalog i18n.Catalog) (domain.Embed, error) {
+	if event.DetailType != alarmStateChangeDetailType {
+		return renderGenericEventBridgeEvent(event, catalog)
+	}
+
+	var detail cloudWatchAlarmStateChange
+	if err := json.Unmarshal(event.Detail, &detail); err != nil {
+		return domain.Embed{}, fmt.Errorf("failed to decode alarm state change: %w", err)
+	}
+
+	alarm := cloudWatchAlarm{
+		AlarmName:      detail.AlarmName,
+		AWSAccountID:   event.Account,
+		NewStateValue:  detail.State.Value,
+		NewStateReason: detail.State.Reason,
+		Region:         event.Region,
+		OldStateValue:  detail.PreviousState.Value,
+	}
+	if len(event.Resources) > 0 {
+		alarm.AlarmArn = event.Resources[0]
+	}
+
+	embed := domain.Embed{
+		Title:       alarm.AlarmName,
+		Description: buildAlarmDescription(alarm, catalog),
+		Fields:      buildAlarmFields(alarm, catalog),
+		Color:       alarmColor(alarm.NewStateValue),
+	}
+	return embed, nil
+}
+
+func alarmStateChangeAttributes(event EventBridgeEvent) map[string]string {
+	if event.Source != "aws.cloudwatch" || event.DetailType != alarmStateChangeDetailType {
+		return nil
+	}
+	var detail cloudWatchAlarmStateChange
+	if err := json.Unmarshal(event.Detail, &detail); err != nil {
+		return nil
+	}
+	return attributes(
+		domain.AttributeAlarmName, detail.AlarmName,
+		domain.AttributeState, strings.ToUpper(detail.State.Value),
+	)
+}
+
+func detectEventBridge(event json.RawMessage) bool {
+	decoded, _, err := decodeEventBridgeEvent(event)
+	return err == nil && decoded.Source != ""
+}
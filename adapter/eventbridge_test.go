@@ -0,0 +1,167 @@
+package adapter
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"lambda-to-discord/domain"
+	"lambda-to-discord/i18n"
+)
+
+const sampleEventBridgeEvent = `{
+  "version": "0",
+  "id": "6a7e8feb-b491-4cf7-a9f1-bf3703467718",
+  "detail-type": "EC2 Instance State-change Notification",
+  "source": "aws.ec2",
+  "account": "123456789012",
+  "time": "2024-01-02T03:04:05Z",
+  "region": "ap-northeast-1",
+  "resources": ["arn:aws:ec2:ap-northeast-1:123456789012:instance/i-1234567890abcdef0"],
+  "detail": {"instance-id": "i-1234567890abcdef0", "state": "terminated"}
+}`
+
+func TestEventBridgeAdapterTransformGeneric(t *testing.T) {
+	payloads, eventMap, err := NewEventBridgeAdapter("https://discord.com/api/webhooks/700/eventbridge").Transform(json.RawMessage(sampleEventBridgeEvent))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	payload := singlePayload(t, payloads)
+	if payload.WebhookURL != "https://discord.com/api/webhooks/700/eventbridge" {
+		t.Fatalf("unexpected webhook: %s", payload.WebhookURL)
+	}
+	if len(payload.Embeds) != 1 {
+		t.Fatalf("expected a single embed, got %d", len(payload.Embeds))
+	}
+	embed := payload.Embeds[0]
+	if embed.Title != "EC2 Instance State-change Notification" {
+		t.Fatalf("unexpected title: %s", embed.Title)
+	}
+	if !strings.Contains(embed.Description, `"state": "terminated"`) {
+		t.Fatalf("expected detail in description: %s", embed.Description)
+	}
+	if embed.Timestamp != "2024-01-02T03:04:05Z" {
+		t.Fatalf("unexpected timestamp: %s", embed.Timestamp)
+	}
+	names := map[string]string{}
+	for _, field := range embed.Fields {
+		names[field.Name] = field.Value
+	}
+	for _, name := range []string{"Source", "Account", "Region", "Time", "Resources"} {
+		if names[name] == "" {
+			t.Fatalf("expected %s field: %#v", name, embed.Fields)
+		}
+	}
+	if eventMap["source"] != "aws.ec2" {
+		t.Fatalf("unexpected event map: %#v", eventMap)
+	}
+}
+
+func TestEventBridgeAdapterUsesSourceRenderer(t *testing.T) {
+	RegisterEventBridgeRenderer("com.example.deploy", func(event EventBridgeEvent, _ i18n.Catalog) (domain.Embed, error) {
+		return domain.Embed{Title: "deployed " + event.Account}, nil
+	})
+	t.Cleanup(func() { RegisterEventBridgeRenderer("com.example.deploy", nil) })
+
+	raw := json.RawMessage(`{"source":"com.example.deploy","detail-type":"Deploy","account":"1","time":"2024-01-02T03:04:05Z","detail":{}}`)
+	payloads, _, err := NewEventBridgeAdapter("https://discord.com/api/webhooks/700/eventbridge").Transform(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	payload := singlePayload(t, payloads)
+	if payload.Embeds[0].Title != "deployed 1" {
+		t.Fatalf("expected custom renderer to be used: %#v", payload.Embeds[0])
+	}
+	if payload.Embeds[0].Timestamp != "2024-01-02T03:04:05Z" {
+		t.Fatalf("expected timestamp to default to event time")
+	}
+}
+
+func TestEventBridgeAdapterCloudWatchAlarmStateChange(t *testing.T) {
+	raw := json.RawMessage(`{
+	  "source": "aws.cloudwatch",
+	  "detail-type": "CloudWatch Alarm State Change",
+	  "account": "123456789012",
+	  "region": "us-east-1",
+	  "time": "2024-01-02T03:04:05Z",
+	  "resources": ["arn:aws:cloudwatch:us-east-1:123456789012:alarm:CPUHigh"],
+	  "detail": {
+	    "alarmName": "CPUHigh",
+	    "state": {"value": "ALARM", "reason": "Threshold Crossed"},
+	    "previousState": {"value": "OK"}
+	  }
+	}`)
+	payloads, _, err := NewEventBridgeAdapter("https://discord.com/api/webhooks/700/eventbridge").Transform(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	payload := singlePayload(t, payloads)
+	embed := payload.Embeds[0]
+	if embed.Title != "CPUHigh" || embed.Description != "Threshold Crossed" || embed.Color != 0xE74C3C {
+		t.Fatalf("unexpected alarm embed: %#v", embed)
+	}
+	if payload.Attributes[domain.AttributeAlarmName] != "CPUHigh" || payload.Attributes[domain.AttributeState] != "ALARM" {
+		t.Fatalf("expected alarm attributes for routing: %#v", payload.Attributes)
+	}
+}
+
+func TestEventBridgeAdapterErrors(t *testing.T) {
+	if _, _, err := NewEventBridgeAdapter("").Transform(json.RawMessage(sampleEventBridgeEvent)); err == nil {
+		t.Fatal("expected error when webhook missing")
+	}
+	if _, _, err := NewEventBridgeAdapter("https://discord.com/api/webhooks/100/hook").Transform(json.RawMessage(`{"content":"hi"}`)); err == nil {
+		t.Fatal("expected error for non-eventbridge payload")
+	}
+
+	RegisterEventBridgeRenderer("com.example.broken", func(EventBridgeEvent, i18n.Catalog) (domain.Embed, error) {
+		return domain.Embed{}, errors.New("boom")
+	})
+	t.Cleanup(func() { RegisterEventBridgeRenderer("com.example.broken", nil) })
+	if _, _, err := NewEventBridgeAdapter("https://discord.com/api/webhooks/100/hook").Transform(json.RawMessage(`{"source":"com.example.broken","detail-type":"x"}`)); err == nil {
+		t.Fatal("expected renderer error to be returned")
+	}
+}
+
+func TestEventBridgeAdapterLocale(t *testing.T) {
+	ja, _ := i18n.Lookup("ja")
+	var resolved map[string]string
+	locale := func(attributes map[string]string, _ map[string]any) i18n.Catalog {
+		resolved = attributes
+		return ja
+	}
+	payloads, _, err := NewEventBridgeAdapter("https://discord.com/api/webhooks/700/eventbridge").
+		WithLocale(locale).
+		Transform(json.RawMessage(sampleEventBridgeEvent))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resolved[domain.AttributeSource] != "aws.ec2" {
+		t.Fatalf("expected the resolver to receive event attributes: %#v", resolved)
+	}
+
+	payload := singlePayload(t, payloads)
+	if payload.Content != ":satellite: aws.ec2 から EC2 Instance State-change Notification を受信しました" {
+		t.Fatalf("unexpected content: %q", payload.Content)
+	}
+	var names []string
+	for _, field := range payload.Embeds[0].Fields {
+		names = append(names, field.Name)
+	}
+	if strings.Join(names, ",") != "ソース,アカウント,リージョン,日時,リソース" {
+		t.Fatalf("unexpected fields: %v", names)
+	}
+	if payload.Attributes[domain.AttributeLocale] != "ja" {
+		t.Fatalf("expected locale attribute: %#v", payload.Attributes)
+	}
+
+	raw := json.RawMessage(`{"source":"aws.cloudwatch","detail-type":"CloudWatch Alarm State Change","account":"1","region":"us-east-1","detail":{"alarmName":"CPUHigh","state":{"value":"ALARM"},"previousState":{"value":"OK"}}}`)
+	payloads, _, err = NewEventBridgeAdapter("https://discord.com/api/webhooks/700/eventbridge").WithLocale(locale).Transform(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fields := singlePayload(t, payloads).Embeds[0].Fields
+	if len(fields) < 4 || fields[2].Name != "変更前の状態" || fields[3].Value != "アラーム" {
+		t.Fatalf("expected alarm state changes to be localised: %#v", fields)
+	}
+}
@@ -0,0 +1,155 @@
+package adapter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"lambda-to-discord/domain"
+	"lambda-to-discord/i18n"
+	"lambda-to-discord/templates"
+)
+
+type Adapter interface {
+	Transform(event json.RawMessage) ([]domain.NotificationPayload, map[string]any, error)
+}
+
+type Config struct {
+	WebhookURL        string
+	Region            string
+	CombineSNSRecords bool
+	AlarmThreads      bool
+	AlarmThreadIDs    map[string]string
+	AlarmSilentStates []string
+	Templates         templates.Set
+	Locale            LocaleResolver
+	DisplayLocation   *time.Location
+}
+
+type LocaleResolver func(attributes map[string]string, event map[string]any) i18n.Catalog
+
+func (r LocaleResolver) catalog(attributes map[string]string, event map[string]any) i18n.Catalog {
+	if r == nil {
+		return i18n.Default()
+	}
+	return r(attributes, event)
+}
+
+func attributes(pairs ...string) map[string]string {
+	values := map[string]string{}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if value := strings.TrimSpace(pairs[i+1]); value != "" {
+			values[pairs[i]] = value
+		}
+	}
+	return values
+}
+
+type Factory func(cfg Config) (Adapter, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[string]Factory{
+		"direct": func(Config) (Adapter, error) {
+			return NewDirectAdapter(), nil
+		},
+		"cloudwatch": func(cfg Config) (Adapter, error) {
+			return NewCloudWatchSNSAdapter(cfg.WebhookURL).
+				WithCombinedRecords(cfg.CombineSNSRecords).
+				WithAlarmThreads(cfg.AlarmThreads, cfg.AlarmThreadIDs).
+				WithSilentStates(cfg.AlarmSilentStates).
+				WithTemplate(cfg.Templates.Lookup("cloudwatch")).
+				WithLocale(cfg.Locale).
+				WithDisplayLocation(cfg.DisplayLocation), nil
+		},
+		"cloudwatch_logs": func(cfg Config) (Adapter, error) {
+			return NewCloudWatchLogsAdapter(cfg.WebhookURL, cfg.Region).
+				WithTemplate(cfg.Templates.Lookup("cloudwatch_logs")).
+				WithLocale(cfg.Locale), nil
+		},
+		"eventbridge": func(cfg Config) (Adapter, error) {
+			return NewEventBridgeAdapter(cfg.WebhookURL).
+				WithTemplate(cfg.Templates.Lookup("eventbridge")).
+				WithLocale(cfg.Locale), nil
+		},
+	}
+)
+
+func Register(name string, factory Factory) {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	if factory == nil {
+		delete(factories, name)
+		return
+	}
+	factories[name] = factory
+}
+
+func New(name string, cfg Config) (Adapter, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	factoriesMu.RLock()
+	factory, ok := factories[name]
+	factoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported adapter type: %s", name)
+	}
+
+	adapter, err := factory(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s adapter: %w", name, err)
+	}
+	return adapter, nil
+}
+
+type Detector func(event json.RawMessage) bool
+
+type detection struct {
+	name   string
+	detect Detector
+}
+
+var (
+	detectorsMu sync.RWMutex
+	detectors   = []detection{
+		{name: "cloudwatch", detect: detectCloudWatchAlarm},
+		{name: "cloudwatch_logs", detect: detectCloudWatchLogs},
+		{name: "eventbridge", detect: detectEventBridge},
+		{name: "direct", detect: detectDirect},
+	}
+)
+
+func RegisterDetector(name string, detector Detector) {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	detectorsMu.Lock()
+	defer detectorsMu.Unlock()
+
+	remaining := make([]detection, 0, len(detectors)+1)
+	for _, d := range detectors {
+		if d.name != name {
+			remaining = append(remaining, d)
+		}
+	}
+	if detector != nil {
+		remaining = append([]detection{{name: name, detect: detector}}, remaining...)
+	}
+	detectors = remaining
+}
+
+func Detect(event json.RawMessage) (string, error) {
+	detectorsMu.RLock()
+	defer detectorsMu.RUnlock()
+
+	for _, d := range detectors {
+		if d.detect(event) {
+			return d.name, nil
+		}
+	}
+	return "", errors.New("could not detect adapter type from event")
+}
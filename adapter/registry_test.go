@@ -0,0 +1,127 @@
+package adapter
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"lambda-to-discord/domain"
+)
+
+type staticAdapter struct {
+	payload domain.NotificationPayload
+}
+
+func (a staticAdapter) Transform(json.RawMessage) ([]domain.NotificationPayload, map[string]any, error) {
+	return []domain.NotificationPayload{a.payload}, nil, nil
+}
+
+func TestNewBuiltInAdapters(t *testing.T) {
+	cfg := Config{WebhookURL: "https://discord.com/api/webhooks/100/hook", Region: "us-east-1", CombineSNSRecords: true}
+
+	for _, name := range []string{"direct", "cloudwatch", "CloudWatch_Logs", "eventbridge"} {
+		if _, err := New(name, cfg); err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+	}
+
+	cloudWatch, _ := New("cloudwatch", cfg)
+	if a, ok := cloudWatch.(CloudWatchSNSAdapter); !ok || a.webhookURL != cfg.WebhookURL || !a.combineRecords {
+		t.Fatalf("unexpected cloudwatch adapter: %#v", cloudWatch)
+	}
+	logs, _ := New("cloudwatch_logs", cfg)
+	if a, ok := logs.(CloudWatchLogsAdapter); !ok || a.region != cfg.Region {
+		t.Fatalf("unexpected cloudwatch logs adapter: %#v", logs)
+	}
+}
+
+func TestNewUnknownAdapter(t *testing.T) {
+	if _, err := New("unknown", Config{}); err == nil {
+		t.Fatal("expected error for unknown adapter")
+	}
+}
+
+func TestRegisterAdapter(t *testing.T) {
+	Register("static", func(cfg Config) (Adapter, error) {
+		return staticAdapter{payload: domain.NotificationPayload{WebhookURL: cfg.WebhookURL, Content: "static"}}, nil
+	})
+	Register("broken", func(Config) (Adapter, error) {
+		return nil, errors.New("boom")
+	})
+	t.Cleanup(func() {
+		Register("static", nil)
+		Register("broken", nil)
+	})
+
+	a, err := New("static", Config{WebhookURL: "https://discord.com/api/webhooks/1000/static"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	payloads, _, err := a.Transform(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if payload := singlePayload(t, payloads); payload.WebhookURL != "https://discord.com/api/webhooks/1000/static" {
+		t.Fatalf("expected config to reach factory: %#v", payload)
+	}
+
+	if _, err := New("broken", Config{}); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("expected factory error, got %v", err)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	alarm, _ := json.Marshal(sampleAlarmMessage)
+	cases := map[string]string{
+		"raw alarm":        sampleAlarmMessage,
+		"sns envelope":     `{"Records":[{"EventSource":"aws:sns","Sns":{"Message":` + string(alarm) + `}}]}`,
+		"sns notification": `{"Type":"Notification","Message":` + string(alarm) + `}`,
+		"logs":             `{"awslogs":{"data":"H4sIAAAAAAAAAA=="}}`,
+		"eventbridge":      sampleEventBridgeEvent,
+		"direct content":   `{"content":"hello"}`,
+		"direct message":   `"{\"message\":\"hello\"}"`,
+	}
+	want := map[string]string{
+		"raw alarm":        "cloudwatch",
+		"sns envelope":     "cloudwatch",
+		"sns notification": "cloudwatch",
+		"logs":             "cloudwatch_logs",
+		"eventbridge":      "eventbridge",
+		"direct content":   "direct",
+		"direct message":   "direct",
+	}
+
+	for name, raw := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := Detect(json.RawMessage(raw))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != want[name] {
+				t.Fatalf("expected %s, got %s", want[name], got)
+			}
+		})
+	}
+}
+
+func TestDetectUnknownEvent(t *testing.T) {
+	if _, err := Detect(json.RawMessage(`{"foo":"bar"}`)); err == nil {
+		t.Fatal("expected error for unknown event")
+	}
+}
+
+func TestRegisterDetectorTakesPrecedence(t *testing.T) {
+	RegisterDetector("custom", func(event json.RawMessage) bool {
+		return strings.Contains(string(event), "x-custom")
+	})
+	t.Cleanup(func() { RegisterDetector("custom", nil) })
+
+	got, err := Detect(json.RawMessage(`{"content":"hello","x-custom":true}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "custom" {
+		t.Fatalf("expected custom detector to win, got %s", got)
+	}
+}
@@ -0,0 +1,32 @@
+package adapter
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+type SQSMessage struct {
+	MessageID   string `json:"messageId"`
+	Body        string `json:"body"`
+	EventSource string `json:"eventSource"`
+}
+
+type sqsEvent struct {
+	Records []SQSMessage `json:"Records"`
+}
+
+func ParseSQSEvent(event json.RawMessage) ([]SQSMessage, bool) {
+	var decoded sqsEvent
+	if err := json.Unmarshal(bytes.TrimSpace(event), &decoded); err != nil {
+		return nil, false
+	}
+	if len(decoded.Records) == 0 {
+		return nil, false
+	}
+	for _, record := range decoded.Records {
+		if record.EventSource != "aws:sqs" {
+			return nil, false
+		}
+	}
+	return decoded.Records, true
+}
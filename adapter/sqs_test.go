@@ -0,0 +1,34 @@
+package adapter
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseSQSEvent(t *testing.T) {
+	raw := json.RawMessage(`{"Records":[
+		{"messageId":"m-1","eventSource":"aws:sqs","body":"{\"content\":\"one\"}"},
+		{"messageId":"m-2","eventSource":"aws:sqs","body":"{\"content\":\"two\"}"}
+	]}`)
+
+	messages, ok := ParseSQSEvent(raw)
+	if !ok {
+		t.Fatal("expected SQS event to be recognised")
+	}
+	if len(messages) != 2 || messages[0].MessageID != "m-1" || messages[1].Body != `{"content":"two"}` {
+		t.Fatalf("unexpected messages: %#v", messages)
+	}
+}
+
+func TestParseSQSEventRejectsOtherEvents(t *testing.T) {
+	for _, raw := range []string{
+		`{"content":"hello"}`,
+		`{"Records":[]}`,
+		`{"Records":[{"EventSource":"aws:sns","Sns":{"Message":"{}"}}]}`,
+		`"just a string"`,
+	} {
+		if _, ok := ParseSQSEvent(json.RawMessage(raw)); ok {
+			t.Fatalf("expected %s not to be treated as SQS", raw)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package discord
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	CodeUnknownChannel = 10003
+	CodeUnknownMessage = 10008
+)
+
+type APIError struct {
+	StatusCode  int
+	Code        int
+	Message     string
+	FieldErrors []FieldError
+	Body        string
+}
+
+type FieldError struct {
+	Path    string
+	Code    string
+	Message string
+}
+
+func (e *APIError) Error() string {
+	if e == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "discord API error (status %d", e.StatusCode)
+	if e.Code != 0 {
+		fmt.Fprintf(&b, ", code %d", e.Code)
+	}
+	b.WriteString(")")
+
+	message := strings.TrimSpace(e.Message)
+	if message == "" {
+		message = strings.TrimSpace(e.Body)
+	}
+	if message != "" {
+		fmt.Fprintf(&b, ": %s", message)
+	}
+
+	for _, fieldErr := range e.FieldErrors {
+		fmt.Fprintf(&b, "; %s: %s", fieldErr.Path, fieldErr.Message)
+	}
+	return b.String()
+}
+
+type apiErrorBody struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Errors  json.RawMessage `json:"errors"`
+}
+
+type apiFieldErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func newAPIError(status int, body string) *APIError {
+	apiErr := &APIError{StatusCode: status, Body: body}
+
+	var decoded apiErrorBody
+	if err := json.Unmarshal([]byte(body), &decoded); err != nil {
+		return apiErr
+	}
+	apiErr.Code = decoded.Code
+	apiErr.Message = decoded.Message
+	if len(decoded.Errors) > 0 {
+		var tree map[string]json.RawMessage
+		if err := json.Unmarshal(decoded.Errors, &tree); err == nil {
+			apiErr.FieldErrors = collectFieldErrors("", tree)
+		}
+	}
+	return apiErr
+}
+
+func collectFieldErrors(path string, tree map[string]json.RawMessage) []FieldError {
+	keys := make([]string, 0, len(tree))
+	for key := range tree {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var fieldErrors []FieldError
+	for _, key := range keys {
+		raw := tree[key]
+		if key == "_errors" {
+			var details []apiFieldErrorDetail
+			if err := json.Unmarshal(raw, &details); err != nil {
+				continue
+			}
+			for _, detail := range details {
+				fieldErrors = append(fieldErrors, FieldError{Path: path, Code: detail.Code, Message: detail.Message})
+			}
+			continue
+		}
+
+		var child map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &child); err != nil {
+			continue
+		}
+		childPath := key
+		if path != "" {
+			childPath = path + "." + key
+		}
+		fieldErrors = append(fieldErrors, collectFieldErrors(childPath, child)...)
+	}
+	return fieldErrors
+}
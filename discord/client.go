@@ -8,9 +8,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	"lambda-to-discord/domain"
+	"lambda-to-discord/redact"
 )
 
 type HTTPClient interface {
@@ -25,7 +28,7 @@ func (e *WebhookError) Error() string {
 	if e == nil {
 		return ""
 	}
-	return fmt.Sprintf("failed to send message to Discord: %v", e.Err)
+	return redact.String(fmt.Sprintf("failed to send message to Discord: %v", e.Err))
 }
 
 func (e *WebhookError) Unwrap() error {
@@ -35,25 +38,189 @@ func (e *WebhookError) Unwrap() error {
 	return e.Err
 }
 
-func Send(ctx context.Context, client HTTPClient, payload domain.NotificationPayload) (int, string, error) {
-	if err := payload.Validate(); err != nil {
-		return 0, "", err
+type Message struct {
+	ID        string `json:"id"`
+	ChannelID string `json:"channel_id"`
+	Timestamp string `json:"timestamp"`
+}
+
+type Result struct {
+	StatusCode int
+	Body       string
+	Messages   []Message
+}
+
+func Send(ctx context.Context, client HTTPClient, payload domain.NotificationPayload, opts ...Option) (Result, error) {
+	if payload.Action != "" && payload.Action != domain.ActionSend {
+		return Result{}, fmt.Errorf("send does not support %s action", payload.Action)
+	}
+
+	options := newSendOptions(opts)
+	if options.componentFallback {
+		payload = payload.WithComponentsAsLinks()
+	}
+
+	parts := payload.Split()
+	for _, part := range parts {
+		if err := part.Validate(); err != nil {
+			return Result{}, err
+		}
+	}
+
+	var (
+		result          Result
+		createdThreadID string
+	)
+	for i, part := range parts {
+		if createdThreadID != "" {
+			part.ThreadID = createdThreadID
+			part.ThreadName = ""
+		}
+		createsThread := part.ThreadName != "" && i < len(parts)-1
+		wait := part.Wait || createsThread
+
+		endpoint, err := webhookEndpoint(part.WebhookURL, endpointParams{
+			threadID:       part.ThreadID,
+			wait:           wait,
+			withComponents: len(part.Components) > 0,
+		})
+		if err != nil {
+			return result, err
+		}
+		body, contentType, err := buildRequestBody(part)
+		if err != nil {
+			return result, err
+		}
+
+		status, respBody, err := deliver(ctx, client, http.MethodPost, endpoint, body, contentType, options.retry)
+		result.StatusCode = status
+		result.Body = respBody
+		if err != nil {
+			return result, err
+		}
+		if !wait {
+			continue
+		}
+
+		var message Message
+		if err := json.Unmarshal([]byte(respBody), &message); err != nil {
+			return result, fmt.Errorf("failed to decode created message: %w", err)
+		}
+		if part.Wait {
+			result.Messages = append(result.Messages, message)
+		}
+		if createsThread {
+			if message.ChannelID == "" {
+				return result, errors.New("discord did not return the created thread")
+			}
+			createdThreadID = message.ChannelID
+		}
+	}
+	return result, nil
+}
+
+type endpointParams struct {
+	messageID      string
+	threadID       string
+	wait           bool
+	withComponents bool
+}
+
+func webhookEndpoint(webhookURL string, params endpointParams) (string, error) {
+	threadID := strings.TrimSpace(params.threadID)
+	if params.messageID == "" && threadID == "" && !params.wait && !params.withComponents {
+		return webhookURL, nil
 	}
 
-	body, err := buildRequestBody(payload)
+	u, err := url.Parse(webhookURL)
 	if err != nil {
-		return 0, "", err
+		return "", redact.Error(fmt.Errorf("invalid webhook URL: %w", err))
+	}
+	if params.messageID != "" {
+		escaped := strings.TrimSuffix(u.EscapedPath(), "/") + "/messages/" + url.PathEscape(params.messageID)
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/messages/" + params.messageID
+		u.RawPath = escaped
+	}
+	query := u.Query()
+	if threadID != "" {
+		query.Set("thread_id", threadID)
+	}
+	if params.wait {
+		query.Set("wait", "true")
+	}
+	if params.withComponents {
+		query.Set("with_components", "true")
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+func deliver(ctx context.Context, client HTTPClient, method, endpoint string, body []byte, contentType string, policy RetryPolicy) (int, string, error) {
+	rateLimited := 0
+	for attempt := 1; ; {
+		status, respBody, header, err := do(ctx, client, method, endpoint, body, contentType)
+		if err != nil {
+			var webhookErr *WebhookError
+			if !errors.As(err, &webhookErr) || ctx.Err() != nil || !policy.canRetry(attempt) {
+				return 0, "", err
+			}
+			if waitErr := waitForRetry(ctx, policy.backoff(attempt)); waitErr != nil {
+				return 0, "", errors.Join(err, waitErr)
+			}
+			attempt++
+			continue
+		}
+
+		if status == http.StatusTooManyRequests {
+			rateLimited++
+			retryAfter, global := parseRetryAfter(header, []byte(respBody))
+			rateLimitErr := &RateLimitError{RetryAfter: retryAfter, Global: global, Attempts: rateLimited, Body: respBody}
+			if rateLimited > maxRateLimitRetries || !fitsDeadline(ctx, retryAfter) {
+				return status, respBody, rateLimitErr
+			}
+			if err := sleep(ctx, retryAfter); err != nil {
+				return status, respBody, errors.Join(rateLimitErr, err)
+			}
+			continue
+		}
+
+		if policy.retryableStatus(status) && policy.canRetry(attempt) {
+			if waitErr := waitForRetry(ctx, policy.backoff(attempt)); waitErr == nil {
+				attempt++
+				continue
+			}
+		}
+
+		if status < 200 || status >= 300 {
+			return status, respBody, newAPIError(status, respBody)
+		}
+		return status, respBody, nil
 	}
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, payload.WebhookURL, bytes.NewReader(body))
+func waitForRetry(ctx context.Context, delay time.Duration) error {
+	if !fitsDeadline(ctx, delay) {
+		return fmt.Errorf("retry delay %s exceeds context deadline", delay)
+	}
+	return sleep(ctx, delay)
+}
+
+func do(ctx context.Context, client HTTPClient, method, endpoint string, body []byte, contentType string) (int, string, http.Header, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
 	if err != nil {
-		return 0, "", fmt.Errorf("failed to create request: %w", err)
+		return 0, "", nil, redact.Error(fmt.Errorf("failed to create request: %w", err))
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
 	}
-	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return 0, "", &WebhookError{Err: err}
+		return 0, "", nil, &WebhookError{Err: err}
 	}
 
 	respBody, readErr := io.ReadAll(resp.Body)
@@ -62,17 +229,17 @@ func Send(ctx context.Context, client HTTPClient, payload domain.NotificationPay
 		if closeErr != nil {
 			readErr = errors.Join(readErr, fmt.Errorf("failed to close response body: %w", closeErr))
 		}
-		return 0, "", fmt.Errorf("failed to read response: %w", readErr)
+		return 0, "", nil, fmt.Errorf("failed to read response: %w", readErr)
 	}
 
 	if closeErr != nil {
-		return 0, "", fmt.Errorf("failed to close response body: %w", closeErr)
+		return 0, "", nil, fmt.Errorf("failed to close response body: %w", closeErr)
 	}
 
-	return resp.StatusCode, string(respBody), nil
+	return resp.StatusCode, string(respBody), resp.Header, nil
 }
 
-func buildRequestBody(payload domain.NotificationPayload) ([]byte, error) {
+func buildRequestBody(payload domain.NotificationPayload) ([]byte, string, error) {
 	body := map[string]any{}
 	if strings.TrimSpace(payload.Content) != "" {
 		body["content"] = payload.Content
@@ -89,10 +256,25 @@ func buildRequestBody(payload domain.NotificationPayload) ([]byte, error) {
 	if strings.TrimSpace(payload.AvatarURL) != "" {
 		body["avatar_url"] = payload.AvatarURL
 	}
+	if strings.TrimSpace(payload.ThreadName) != "" {
+		body["thread_name"] = payload.ThreadName
+	}
+	if len(payload.Components) > 0 {
+		body["components"] = payload.Components
+	}
+	if payload.Flags != 0 {
+		body["flags"] = payload.Flags
+	}
+	if len(payload.Attachments) > 0 {
+		body["attachments"] = attachmentMetadata(payload.Attachments)
+	}
 
 	encoded, err := json.Marshal(body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+		return nil, "", fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	if len(payload.Attachments) > 0 {
+		return encodeMultipart(encoded, payload.Attachments)
 	}
-	return encoded, nil
+	return encoded, jsonContentType, nil
 }
@@ -5,23 +5,33 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"lambda-to-discord/domain"
 )
 
 type stubHTTPClient struct {
-	req  *http.Request
-	resp *http.Response
-	err  error
+	req       *http.Request
+	reqs      []*http.Request
+	resp      *http.Response
+	responses []*http.Response
+	err       error
 }
 
 func (s *stubHTTPClient) Do(req *http.Request) (*http.Response, error) {
 	s.req = req
+	s.reqs = append(s.reqs, req)
 	if s.err != nil {
 		return nil, s.err
 	}
+	if len(s.responses) > 0 {
+		resp := s.responses[0]
+		s.responses = s.responses[1:]
+		return resp, nil
+	}
 	if s.resp == nil {
 		return &http.Response{StatusCode: http.StatusNoContent, Body: io.NopCloser(strings.NewReader(""))}, nil
 	}
@@ -30,17 +40,17 @@ func (s *stubHTTPClient) Do(req *http.Request) (*http.Response, error) {
 
 func TestSendSuccess(t *testing.T) {
 	stub := &stubHTTPClient{resp: &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok"))}}
-	payload := domain.NotificationPayload{WebhookURL: "https://discord.example/hook", Content: "hello"}
+	payload := domain.NotificationPayload{WebhookURL: "https://discord.com/api/webhooks/100/hook", Content: "hello"}
 
-	status, body, err := Send(context.Background(), stub, payload)
+	result, err := Send(context.Background(), stub, payload)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if status != http.StatusOK {
-		t.Fatalf("unexpected status: %d", status)
+	if result.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status: %d", result.StatusCode)
 	}
-	if body != "ok" {
-		t.Fatalf("unexpected body: %s", body)
+	if result.Body != "ok" {
+		t.Fatalf("unexpected body: %s", result.Body)
 	}
 	if stub.req == nil {
 		t.Fatalf("expected request to be sent")
@@ -51,9 +61,10 @@ func TestSendSuccess(t *testing.T) {
 }
 
 func TestSendNetworkError(t *testing.T) {
+	stubSleep(t)
 	stub := &stubHTTPClient{err: errors.New("boom")}
-	payload := domain.NotificationPayload{WebhookURL: "https://discord.example/hook", Content: "hello"}
-	_, _, err := Send(context.Background(), stub, payload)
+	payload := domain.NotificationPayload{WebhookURL: "https://discord.com/api/webhooks/100/hook", Content: "hello"}
+	_, err := Send(context.Background(), stub, payload)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -64,7 +75,381 @@ func TestSendNetworkError(t *testing.T) {
 }
 
 func TestSendValidation(t *testing.T) {
-	if _, _, err := Send(context.Background(), &stubHTTPClient{}, domain.NotificationPayload{}); err == nil {
+	if _, err := Send(context.Background(), &stubHTTPClient{}, domain.NotificationPayload{}); err == nil {
+		t.Fatal("expected validation error")
+	}
+}
+
+func newResponse(status int, body string, header http.Header) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{StatusCode: status, Header: header, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+func stubSleep(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var waits []time.Duration
+	oldSleep := sleep
+	sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return ctx.Err()
+	}
+	t.Cleanup(func() { sleep = oldSleep })
+	return &waits
+}
+
+func TestSendRetriesAfterRateLimit(t *testing.T) {
+	waits := stubSleep(t)
+	stub := &stubHTTPClient{responses: []*http.Response{
+		newResponse(http.StatusTooManyRequests, `{"message":"You are being rate limited.","retry_after":0.25,"global":false}`, nil),
+		newResponse(http.StatusNoContent, "", nil),
+	}}
+	payload := domain.NotificationPayload{WebhookURL: "https://discord.com/api/webhooks/100/hook", Content: "hello"}
+
+	result, err := Send(context.Background(), stub, payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.StatusCode != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d", result.StatusCode)
+	}
+	if len(stub.reqs) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(stub.reqs))
+	}
+	if len(*waits) != 1 || (*waits)[0] != 250*time.Millisecond {
+		t.Fatalf("unexpected waits: %v", *waits)
+	}
+	body, _ := io.ReadAll(stub.reqs[1].Body)
+	if !strings.Contains(string(body), "hello") {
+		t.Fatalf("expected retried request to carry payload, got %s", string(body))
+	}
+}
+
+func TestSendRateLimitExhausted(t *testing.T) {
+	stubSleep(t)
+	var responses []*http.Response
+	for i := 0; i <= maxRateLimitRetries; i++ {
+		responses = append(responses, newResponse(http.StatusTooManyRequests, "", http.Header{"Retry-After": []string{"2"}}))
+	}
+	stub := &stubHTTPClient{responses: responses}
+	payload := domain.NotificationPayload{WebhookURL: "https://discord.com/api/webhooks/100/hook", Content: "hello"}
+
+	result, err := Send(context.Background(), stub, payload)
+	var rateLimitErr *RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("expected rate limit error, got %v", err)
+	}
+	if result.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("unexpected status: %d", result.StatusCode)
+	}
+	if rateLimitErr.Attempts != maxRateLimitRetries+1 || rateLimitErr.RetryAfter != 2*time.Second {
+		t.Fatalf("unexpected rate limit error: %#v", rateLimitErr)
+	}
+}
+
+func TestSendRateLimitBeyondDeadline(t *testing.T) {
+	waits := stubSleep(t)
+	stub := &stubHTTPClient{resp: newResponse(http.StatusTooManyRequests, "", http.Header{"X-Ratelimit-Reset-After": []string{"30"}})}
+	payload := domain.NotificationPayload{WebhookURL: "https://discord.com/api/webhooks/100/hook", Content: "hello"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_, err := Send(ctx, stub, payload)
+	var rateLimitErr *RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("expected rate limit error, got %v", err)
+	}
+	if len(*waits) != 0 {
+		t.Fatalf("expected no wait beyond the deadline, got %v", *waits)
+	}
+	if len(stub.reqs) != 1 {
+		t.Fatalf("expected a single request, got %d", len(stub.reqs))
+	}
+}
+
+func TestSendRetriesServerErrors(t *testing.T) {
+	waits := stubSleep(t)
+	stub := &stubHTTPClient{responses: []*http.Response{
+		newResponse(http.StatusBadGateway, "bad gateway", nil),
+		newResponse(http.StatusServiceUnavailable, "unavailable", nil),
+		newResponse(http.StatusNoContent, "", nil),
+	}}
+	payload := domain.NotificationPayload{WebhookURL: "https://discord.com/api/webhooks/100/hook", Content: "hello"}
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second, RetryableStatuses: []int{http.StatusBadGateway, http.StatusServiceUnavailable}}
+
+	result, err := Send(context.Background(), stub, payload, WithRetryPolicy(policy))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.StatusCode != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d", result.StatusCode)
+	}
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}
+	if len(*waits) != len(want) || (*waits)[0] != want[0] || (*waits)[1] != want[1] {
+		t.Fatalf("unexpected waits: %v", *waits)
+	}
+}
+
+func TestSendRetriesNetworkErrorsUntilExhausted(t *testing.T) {
+	stubSleep(t)
+	stub := &stubHTTPClient{err: errors.New("connection reset")}
+	payload := domain.NotificationPayload{WebhookURL: "https://discord.com/api/webhooks/100/hook", Content: "hello"}
+	policy := RetryPolicy{MaxAttempts: 4, BaseDelay: time.Millisecond}
+
+	_, err := Send(context.Background(), stub, payload, WithRetryPolicy(policy))
+	var webhookErr *WebhookError
+	if !errors.As(err, &webhookErr) {
+		t.Fatalf("expected webhook error, got %v", err)
+	}
+	if len(stub.reqs) != 4 {
+		t.Fatalf("expected 4 attempts, got %d", len(stub.reqs))
+	}
+}
+
+func TestSendDoesNotRetryClientErrors(t *testing.T) {
+	stubSleep(t)
+	stub := &stubHTTPClient{resp: newResponse(http.StatusBadRequest, "bad request", nil)}
+	payload := domain.NotificationPayload{WebhookURL: "https://discord.com/api/webhooks/100/hook", Content: "hello"}
+
+	result, err := Send(context.Background(), stub, payload)
+	if result.StatusCode != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", result.StatusCode)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected API error, got %v", err)
+	}
+	if len(stub.reqs) != 1 {
+		t.Fatalf("expected a single attempt, got %d", len(stub.reqs))
+	}
+}
+
+func TestSendReturnsAPIErrorForNon2xx(t *testing.T) {
+	stubSleep(t)
+	body := `{"code": 50035, "errors": {"embeds": {"0": {"description": {"_errors": [{"code": "BASE_TYPE_MAX_LENGTH", "message": "Must be 4096 or fewer in length."}]}}}}, "message": "Invalid Form Body"}`
+	stub := &stubHTTPClient{resp: newResponse(http.StatusBadRequest, body, nil)}
+	payload := domain.NotificationPayload{WebhookURL: "https://discord.com/api/webhooks/100/hook", Content: "hello"}
+
+	_, err := Send(context.Background(), stub, payload)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected API error, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.Code != 50035 || apiErr.Message != "Invalid Form Body" {
+		t.Fatalf("unexpected API error: %#v", apiErr)
+	}
+	if len(apiErr.FieldErrors) != 1 {
+		t.Fatalf("expected a single field error, got %#v", apiErr.FieldErrors)
+	}
+	fieldErr := apiErr.FieldErrors[0]
+	if fieldErr.Path != "embeds.0.description" || fieldErr.Code != "BASE_TYPE_MAX_LENGTH" {
+		t.Fatalf("unexpected field error: %#v", fieldErr)
+	}
+	if !strings.Contains(err.Error(), "embeds.0.description") {
+		t.Fatalf("expected field path in error message: %s", err.Error())
+	}
+}
+
+func TestSendReturnsAPIErrorAfterServerErrorRetries(t *testing.T) {
+	stubSleep(t)
+	stub := &stubHTTPClient{responses: []*http.Response{
+		newResponse(http.StatusServiceUnavailable, "upstream unavailable", nil),
+		newResponse(http.StatusServiceUnavailable, "upstream unavailable", nil),
+	}}
+	payload := domain.NotificationPayload{WebhookURL: "https://discord.com/api/webhooks/100/hook", Content: "hello"}
+
+	_, err := Send(context.Background(), stub, payload, WithRetryPolicy(RetryPolicy{MaxAttempts: 2, RetryableStatuses: []int{http.StatusServiceUnavailable}}))
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected API error, got %v", err)
+	}
+	if apiErr.Message != "" || !strings.Contains(apiErr.Error(), "upstream unavailable") {
+		t.Fatalf("expected raw body in error: %v", apiErr)
+	}
+}
+
+func TestSendSplitsOversizedPayload(t *testing.T) {
+	stub := &stubHTTPClient{}
+	payload := domain.NotificationPayload{
+		WebhookURL: "https://discord.com/api/webhooks/100/hook",
+		Content:    strings.Repeat("word ", 1000),
+	}
+
+	if _, err := Send(context.Background(), stub, payload); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stub.reqs) != 3 {
+		t.Fatalf("expected 3 sequential messages, got %d", len(stub.reqs))
+	}
+	for _, req := range stub.reqs {
+		if req.URL.String() != payload.WebhookURL {
+			t.Fatalf("unexpected webhook: %s", req.URL.String())
+		}
+	}
+}
+
+func TestSendToThread(t *testing.T) {
+	stub := &stubHTTPClient{}
+	payload := domain.NotificationPayload{WebhookURL: "https://discord.com/api/webhooks/100/hook?foo=bar", Content: "hello", ThreadID: "1234"}
+
+	if _, err := Send(context.Background(), stub, payload); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	query := stub.req.URL.Query()
+	if query.Get("thread_id") != "1234" || query.Get("foo") != "bar" {
+		t.Fatalf("unexpected query: %s", stub.req.URL.RawQuery)
+	}
+}
+
+func TestSendCreatesForumPost(t *testing.T) {
+	stub := &stubHTTPClient{}
+	payload := domain.NotificationPayload{WebhookURL: "https://discord.com/api/webhooks/100/hook", Content: "hello", ThreadName: "CPUHigh"}
+
+	if _, err := Send(context.Background(), stub, payload); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(stub.req.Body)
+	if !strings.Contains(string(body), `"thread_name":"CPUHigh"`) {
+		t.Fatalf("expected thread name in body: %s", string(body))
+	}
+	if stub.req.URL.RawQuery != "" {
+		t.Fatalf("expected no query for a single message, got %s", stub.req.URL.RawQuery)
+	}
+}
+
+func TestSendSplitPayloadContinuesInCreatedThread(t *testing.T) {
+	stub := &stubHTTPClient{responses: []*http.Response{
+		newResponse(http.StatusOK, `{"id":"1","channel_id":"999"}`, nil),
+		newResponse(http.StatusNoContent, "", nil),
+	}}
+	payload := domain.NotificationPayload{
+		WebhookURL: "https://discord.com/api/webhooks/100/hook",
+		Content:    strings.Repeat("word ", 500),
+		ThreadName: "CPUHigh",
+	}
+
+	if _, err := Send(context.Background(), stub, payload); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stub.reqs) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(stub.reqs))
+	}
+	if stub.reqs[0].URL.Query().Get("wait") != "true" {
+		t.Fatalf("expected first request to wait for the created thread: %s", stub.reqs[0].URL)
+	}
+	if stub.reqs[1].URL.Query().Get("thread_id") != "999" {
+		t.Fatalf("expected follow-up to target the created thread: %s", stub.reqs[1].URL)
+	}
+	body, _ := io.ReadAll(stub.reqs[1].Body)
+	if strings.Contains(string(body), "thread_name") {
+		t.Fatalf("follow-up must not create another thread: %s", string(body))
+	}
+}
+
+func TestSendRejectsThreadIDWithThreadName(t *testing.T) {
+	payload := domain.NotificationPayload{WebhookURL: "https://discord.com/api/webhooks/100/hook", Content: "hello", ThreadID: "1", ThreadName: "x"}
+	if _, err := Send(context.Background(), &stubHTTPClient{}, payload); err == nil {
 		t.Fatal("expected validation error")
 	}
 }
+
+func TestSendWaitReturnsCreatedMessage(t *testing.T) {
+	stub := &stubHTTPClient{resp: newResponse(http.StatusOK, `{"id":"111","channel_id":"222","timestamp":"2024-01-02T03:04:05.678000+00:00","content":"hello"}`, nil)}
+	payload := domain.NotificationPayload{WebhookURL: "https://discord.com/api/webhooks/100/hook", Content: "hello", ThreadID: "222", Wait: true}
+
+	result, err := Send(context.Background(), stub, payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	query := stub.req.URL.Query()
+	if query.Get("wait") != "true" || query.Get("thread_id") != "222" {
+		t.Fatalf("unexpected query: %s", stub.req.URL.RawQuery)
+	}
+	if len(result.Messages) != 1 {
+		t.Fatalf("expected created message, got %#v", result.Messages)
+	}
+	message := result.Messages[0]
+	if message.ID != "111" || message.ChannelID != "222" || message.Timestamp != "2024-01-02T03:04:05.678000+00:00" {
+		t.Fatalf("unexpected message: %#v", message)
+	}
+}
+
+func TestSendWaitCollectsEverySplitMessage(t *testing.T) {
+	stub := &stubHTTPClient{responses: []*http.Response{
+		newResponse(http.StatusOK, `{"id":"1","channel_id":"9"}`, nil),
+		newResponse(http.StatusOK, `{"id":"2","channel_id":"9"}`, nil),
+	}}
+	payload := domain.NotificationPayload{WebhookURL: "https://discord.com/api/webhooks/100/hook", Content: strings.Repeat("word ", 500), Wait: true}
+
+	result, err := Send(context.Background(), stub, payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Messages) != 2 || result.Messages[0].ID != "1" || result.Messages[1].ID != "2" {
+		t.Fatalf("unexpected messages: %#v", result.Messages)
+	}
+}
+
+func TestSendComponents(t *testing.T) {
+	payload := domain.NotificationPayload{
+		WebhookURL: "https://discord.com/api/webhooks/100/hook",
+		Content:    "alarm",
+		Components: []domain.Component{domain.ActionRow(domain.LinkButton("Open alarm", "https://example.com/alarm"))},
+	}
+
+	stub := &stubHTTPClient{}
+	if _, err := Send(context.Background(), stub, payload); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := stub.req.URL.String(); got != "https://discord.com/api/webhooks/100/hook?with_components=true" {
+		t.Fatalf("unexpected endpoint: %s", got)
+	}
+	body, _ := io.ReadAll(stub.req.Body)
+	if !strings.Contains(string(body), `"components":[{"type":1,"components":[{"type":2,"style":5,"label":"Open alarm","url":"https://example.com/alarm"}]}]`) {
+		t.Fatalf("expected components in body: %s", body)
+	}
+
+	stub = &stubHTTPClient{}
+	if _, err := Send(context.Background(), stub, payload, WithComponentFallback(true)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := stub.req.URL.String(); got != "https://discord.com/api/webhooks/100/hook" {
+		t.Fatalf("unexpected endpoint: %s", got)
+	}
+	body, _ = io.ReadAll(stub.req.Body)
+	if strings.Contains(string(body), "components") || !strings.Contains(string(body), `[Open alarm](https://example.com/alarm)`) {
+		t.Fatalf("expected components rendered as links: %s", body)
+	}
+}
+
+func TestSendFlags(t *testing.T) {
+	stub := &stubHTTPClient{}
+	payload := domain.NotificationPayload{WebhookURL: "https://discord.com/api/webhooks/100/hook", Content: "hello", Flags: domain.FlagSuppressNotifications}
+
+	if _, err := Send(context.Background(), stub, payload); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(stub.req.Body)
+	if !strings.Contains(string(body), `"flags":4096`) {
+		t.Fatalf("expected flags in body: %s", body)
+	}
+}
+
+func TestWebhookErrorRedactsToken(t *testing.T) {
+	err := &WebhookError{Err: &url.Error{Op: "Post", URL: "https://discord.com/api/webhooks/100/sekrit?wait=true", Err: errors.New("timeout")}}
+	if strings.Contains(err.Error(), "sekrit") || !strings.Contains(err.Error(), "webhooks/100/[REDACTED]?wait=true") {
+		t.Fatalf("expected token to be redacted: %s", err.Error())
+	}
+}
+
+func TestWebhookEndpointEscapesMessageID(t *testing.T) {
+	endpoint, err := webhookEndpoint("https://discord.com/api/webhooks/100/hook", endpointParams{messageID: "../../1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if endpoint != "https://discord.com/api/webhooks/100/hook/messages/..%2F..%2F1" {
+		t.Fatalf("expected the message id to stay within one path segment: %s", endpoint)
+	}
+}
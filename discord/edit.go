@@ -0,0 +1,94 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"lambda-to-discord/domain"
+)
+
+func Edit(ctx context.Context, client HTTPClient, webhookURL, messageID string, payload domain.NotificationPayload, opts ...Option) (Result, error) {
+	payload.Action = domain.ActionEdit
+	payload.WebhookURL = webhookURL
+	payload.MessageID = strings.TrimSpace(messageID)
+
+	options := newSendOptions(opts)
+	if options.componentFallback {
+		payload = payload.WithComponentsAsLinks()
+	}
+	if err := payload.Validate(); err != nil {
+		return Result{}, err
+	}
+
+	endpoint, err := webhookEndpoint(webhookURL, endpointParams{
+		messageID:      payload.MessageID,
+		threadID:       payload.ThreadID,
+		withComponents: len(payload.Components) > 0,
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	body, err := buildEditRequestBody(payload)
+	if err != nil {
+		return Result{}, err
+	}
+
+	status, respBody, err := deliver(ctx, client, http.MethodPatch, endpoint, body, jsonContentType, options.retry)
+	result := Result{StatusCode: status, Body: respBody}
+	if err != nil {
+		return result, err
+	}
+
+	var message Message
+	if err := json.Unmarshal([]byte(respBody), &message); err != nil {
+		return result, fmt.Errorf("failed to decode edited message: %w", err)
+	}
+	result.Messages = []Message{message}
+	return result, nil
+}
+
+func Delete(ctx context.Context, client HTTPClient, webhookURL, messageID, threadID string, opts ...Option) error {
+	payload := domain.NotificationPayload{
+		Action:     domain.ActionDelete,
+		WebhookURL: webhookURL,
+		MessageID:  strings.TrimSpace(messageID),
+		ThreadID:   threadID,
+	}
+	if err := payload.Validate(); err != nil {
+		return err
+	}
+
+	endpoint, err := webhookEndpoint(webhookURL, endpointParams{messageID: payload.MessageID, threadID: threadID})
+	if err != nil {
+		return err
+	}
+
+	options := newSendOptions(opts)
+	_, _, err = deliver(ctx, client, http.MethodDelete, endpoint, nil, "", options.retry)
+	return err
+}
+
+func buildEditRequestBody(payload domain.NotificationPayload) ([]byte, error) {
+	body := map[string]any{}
+	if strings.TrimSpace(payload.Content) != "" {
+		body["content"] = payload.Content
+	}
+	if len(payload.Embeds) > 0 {
+		body["embeds"] = payload.Embeds
+	}
+	if payload.AllowedMentions != nil {
+		body["allowed_mentions"] = payload.AllowedMentions
+	}
+	if len(payload.Components) > 0 {
+		body["components"] = payload.Components
+	}
+
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	return encoded, nil
+}
@@ -0,0 +1,98 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"lambda-to-discord/domain"
+)
+
+func TestEdit(t *testing.T) {
+	stub := &stubHTTPClient{resp: newResponse(http.StatusOK, `{"id":"111","channel_id":"222","timestamp":"2024-01-02T03:04:05+00:00"}`, nil)}
+	payload := domain.NotificationPayload{Content: "deploy succeeded", Username: "ignored", ThreadID: "333"}
+
+	result, err := Edit(context.Background(), stub, "https://discord.com/api/webhooks/1/token", "111", payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stub.req.Method != http.MethodPatch {
+		t.Fatalf("unexpected method: %s", stub.req.Method)
+	}
+	if stub.req.URL.Path != "/api/webhooks/1/token/messages/111" {
+		t.Fatalf("unexpected path: %s", stub.req.URL.Path)
+	}
+	if stub.req.URL.Query().Get("thread_id") != "333" {
+		t.Fatalf("expected thread id in query: %s", stub.req.URL.RawQuery)
+	}
+	body, _ := io.ReadAll(stub.req.Body)
+	if !strings.Contains(string(body), "deploy succeeded") || strings.Contains(string(body), "username") {
+		t.Fatalf("unexpected edit body: %s", string(body))
+	}
+	if len(result.Messages) != 1 || result.Messages[0].ID != "111" {
+		t.Fatalf("unexpected messages: %#v", result.Messages)
+	}
+}
+
+func TestEditValidation(t *testing.T) {
+	if _, err := Edit(context.Background(), &stubHTTPClient{}, "https://discord.com/api/webhooks/100/hook", "", domain.NotificationPayload{Content: "x"}); err == nil {
+		t.Fatal("expected error when message id missing")
+	}
+	if _, err := Edit(context.Background(), &stubHTTPClient{}, "https://discord.com/api/webhooks/100/hook", "1", domain.NotificationPayload{}); err == nil {
+		t.Fatal("expected error when content missing")
+	}
+	oversized := domain.NotificationPayload{Content: strings.Repeat("a", domain.MaxContentLength+1)}
+	if _, err := Edit(context.Background(), &stubHTTPClient{}, "https://discord.com/api/webhooks/100/hook", "1", oversized); err == nil {
+		t.Fatal("expected error for content that cannot be split into one message")
+	}
+}
+
+func TestDelete(t *testing.T) {
+	stub := &stubHTTPClient{}
+
+	if err := Delete(context.Background(), stub, "https://discord.com/api/webhooks/1/token", "111", "333"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stub.req.Method != http.MethodDelete {
+		t.Fatalf("unexpected method: %s", stub.req.Method)
+	}
+	if stub.req.URL.Path != "/api/webhooks/1/token/messages/111" || stub.req.URL.Query().Get("thread_id") != "333" {
+		t.Fatalf("unexpected url: %s", stub.req.URL)
+	}
+	if stub.req.Body != nil && stub.req.Body != http.NoBody {
+		t.Fatalf("expected no request body")
+	}
+}
+
+func TestDeleteUnknownMessage(t *testing.T) {
+	stub := &stubHTTPClient{resp: newResponse(http.StatusNotFound, `{"message":"Unknown Message","code":10008}`, nil)}
+
+	err := Delete(context.Background(), stub, "https://discord.com/api/webhooks/100/hook", "111", "")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != 10008 {
+		t.Fatalf("expected unknown message error, got %v", err)
+	}
+}
+
+func TestSendRejectsEditAction(t *testing.T) {
+	payload := domain.NotificationPayload{Action: domain.ActionEdit, MessageID: "1", WebhookURL: "https://discord.com/api/webhooks/100/hook", Content: "x"}
+	if _, err := Send(context.Background(), &stubHTTPClient{}, payload); err == nil {
+		t.Fatal("expected error for edit action")
+	}
+}
+
+func TestEditRejectsNonSnowflakeMessageID(t *testing.T) {
+	stub := &stubHTTPClient{}
+	if _, err := Edit(context.Background(), stub, "https://discord.com/api/webhooks/100/hook", "../../../channels/1", domain.NotificationPayload{Content: "x"}); err == nil || !strings.Contains(err.Error(), "snowflake") {
+		t.Fatalf("expected invalid message id error, got %v", err)
+	}
+	if err := Delete(context.Background(), stub, "https://discord.com/api/webhooks/100/hook", "1?wait=true", ""); err == nil {
+		t.Fatal("expected invalid message id error")
+	}
+	if stub.req != nil {
+		t.Fatalf("expected no request for an invalid message id: %s", stub.req.URL)
+	}
+}
@@ -0,0 +1,71 @@
+package discord
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+
+	"lambda-to-discord/domain"
+)
+
+const jsonContentType = "application/json"
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+type attachmentInfo struct {
+	ID          int    `json:"id"`
+	Filename    string `json:"filename"`
+	Description string `json:"description,omitempty"`
+}
+
+func attachmentMetadata(attachments []domain.Attachment) []attachmentInfo {
+	infos := make([]attachmentInfo, 0, len(attachments))
+	for i, attachment := range attachments {
+		infos = append(infos, attachmentInfo{
+			ID:          i,
+			Filename:    attachment.Name,
+			Description: attachment.Description,
+		})
+	}
+	return infos
+}
+
+func encodeMultipart(payloadJSON []byte, attachments []domain.Attachment) ([]byte, string, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="payload_json"`)
+	header.Set("Content-Type", jsonContentType)
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode payload_json: %w", err)
+	}
+	if _, err := part.Write(payloadJSON); err != nil {
+		return nil, "", fmt.Errorf("failed to encode payload_json: %w", err)
+	}
+
+	for i, attachment := range attachments {
+		contentType := strings.TrimSpace(attachment.ContentType)
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="files[%d]"; filename="%s"`, i, quoteEscaper.Replace(attachment.Name)))
+		header.Set("Content-Type", contentType)
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to encode attachment %s: %w", attachment.Name, err)
+		}
+		if _, err := part.Write(attachment.Data); err != nil {
+			return nil, "", fmt.Errorf("failed to encode attachment %s: %w", attachment.Name, err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to encode multipart body: %w", err)
+	}
+	return buf.Bytes(), writer.FormDataContentType(), nil
+}
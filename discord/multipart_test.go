@@ -0,0 +1,90 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"strings"
+	"testing"
+
+	"lambda-to-discord/domain"
+)
+
+func TestSendAttachmentsAsMultipart(t *testing.T) {
+	stub := &stubHTTPClient{}
+	payload := domain.NotificationPayload{
+		WebhookURL: "https://discord.com/api/webhooks/100/hook",
+		Content:    "see attached",
+		Attachments: []domain.Attachment{
+			{Name: "request.json", ContentType: "application/json", Data: []byte(`{"a":1}`), Description: "request dump"},
+			{Name: "trace.txt", Data: []byte("line 1")},
+		},
+	}
+
+	if _, err := Send(context.Background(), stub, payload); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(stub.req.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" {
+		t.Fatalf("expected multipart body: %s %v", mediaType, err)
+	}
+	reader := multipart.NewReader(stub.req.Body, params["boundary"])
+
+	part, err := reader.NextPart()
+	if err != nil || part.FormName() != "payload_json" {
+		t.Fatalf("expected payload_json first: %v", err)
+	}
+	var body struct {
+		Content     string           `json:"content"`
+		Attachments []attachmentInfo `json:"attachments"`
+	}
+	if err := json.NewDecoder(part).Decode(&body); err != nil {
+		t.Fatalf("failed to decode payload_json: %v", err)
+	}
+	if body.Content != "see attached" || len(body.Attachments) != 2 {
+		t.Fatalf("unexpected payload_json: %#v", body)
+	}
+	if body.Attachments[0].Filename != "request.json" || body.Attachments[0].Description != "request dump" || body.Attachments[1].ID != 1 {
+		t.Fatalf("unexpected attachment metadata: %#v", body.Attachments)
+	}
+
+	wantFiles := []struct{ name, filename, contentType, data string }{
+		{"files[0]", "request.json", "application/json", `{"a":1}`},
+		{"files[1]", "trace.txt", "application/octet-stream", "line 1"},
+	}
+	for _, want := range wantFiles {
+		part, err := reader.NextPart()
+		if err != nil {
+			t.Fatalf("expected %s: %v", want.name, err)
+		}
+		data, _ := io.ReadAll(part)
+		if part.FormName() != want.name || part.FileName() != want.filename || part.Header.Get("Content-Type") != want.contentType || string(data) != want.data {
+			t.Fatalf("unexpected part %s: %s %s %q", part.FormName(), part.FileName(), part.Header.Get("Content-Type"), data)
+		}
+	}
+}
+
+func TestSendAttachmentsOnlyOnFirstPart(t *testing.T) {
+	stub := &stubHTTPClient{}
+	payload := domain.NotificationPayload{
+		WebhookURL:  "https://discord.com/api/webhooks/100/hook",
+		Content:     strings.Repeat("a", domain.MaxContentLength+10),
+		Attachments: []domain.Attachment{{Name: "request.json", Data: []byte("{}")}},
+	}
+
+	if _, err := Send(context.Background(), stub, payload); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stub.reqs) != 2 {
+		t.Fatalf("expected two messages, got %d", len(stub.reqs))
+	}
+	if !strings.HasPrefix(stub.reqs[0].Header.Get("Content-Type"), "multipart/form-data") {
+		t.Fatalf("expected first part to carry the attachment: %s", stub.reqs[0].Header.Get("Content-Type"))
+	}
+	if stub.reqs[1].Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("expected later parts to be plain JSON: %s", stub.reqs[1].Header.Get("Content-Type"))
+	}
+}
@@ -0,0 +1,89 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxRateLimitRetries     = 3
+	defaultRateLimitBackoff = time.Second
+)
+
+type RateLimitError struct {
+	RetryAfter time.Duration
+	Global     bool
+	Attempts   int
+	Body       string
+}
+
+func (e *RateLimitError) Error() string {
+	if e == nil {
+		return ""
+	}
+	scope := "webhook"
+	if e.Global {
+		scope = "global"
+	}
+	return fmt.Sprintf("discord %s rate limit exceeded after %d attempts (retry after %s)", scope, e.Attempts, e.RetryAfter)
+}
+
+type rateLimitBody struct {
+	RetryAfter float64 `json:"retry_after"`
+	Global     bool    `json:"global"`
+}
+
+func parseRetryAfter(header http.Header, body []byte) (time.Duration, bool) {
+	global := strings.EqualFold(strings.TrimSpace(header.Get("X-RateLimit-Global")), "true")
+
+	var decoded rateLimitBody
+	if err := json.Unmarshal(body, &decoded); err == nil {
+		global = global || decoded.Global
+		if decoded.RetryAfter > 0 {
+			return secondsToDuration(decoded.RetryAfter), global
+		}
+	}
+
+	for _, key := range []string{"X-RateLimit-Reset-After", "Retry-After"} {
+		value := strings.TrimSpace(header.Get(key))
+		if value == "" {
+			continue
+		}
+		if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+			return secondsToDuration(seconds), global
+		}
+	}
+
+	return defaultRateLimitBackoff, global
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+var sleep = func(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func fitsDeadline(ctx context.Context, wait time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return true
+	}
+	return time.Until(deadline) > wait
+}
@@ -0,0 +1,54 @@
+package discord
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	cases := []struct {
+		name       string
+		header     http.Header
+		body       string
+		wantWait   time.Duration
+		wantGlobal bool
+	}{
+		{
+			name:       "body",
+			header:     http.Header{"Retry-After": []string{"5"}},
+			body:       `{"retry_after":1.5,"global":true}`,
+			wantWait:   1500 * time.Millisecond,
+			wantGlobal: true,
+		},
+		{
+			name:     "reset after header",
+			header:   http.Header{"X-Ratelimit-Reset-After": []string{"0.75"}, "Retry-After": []string{"5"}},
+			wantWait: 750 * time.Millisecond,
+		},
+		{
+			name:       "retry after header",
+			header:     http.Header{"Retry-After": []string{"3"}, "X-Ratelimit-Global": []string{"true"}},
+			wantWait:   3 * time.Second,
+			wantGlobal: true,
+		},
+		{
+			name:     "fallback",
+			header:   http.Header{},
+			body:     "not json",
+			wantWait: defaultRateLimitBackoff,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			wait, global := parseRetryAfter(tc.header, []byte(tc.body))
+			if wait != tc.wantWait {
+				t.Fatalf("unexpected wait: %s", wait)
+			}
+			if global != tc.wantGlobal {
+				t.Fatalf("unexpected global flag: %v", global)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package discord
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+type RetryPolicy struct {
+	MaxAttempts       int
+	BaseDelay         time.Duration
+	MaxDelay          time.Duration
+	Jitter            float64
+	RetryableStatuses []int
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+		Jitter:      0.2,
+		RetryableStatuses: []int{
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+func (p RetryPolicy) canRetry(attempt int) bool {
+	return attempt < p.MaxAttempts
+}
+
+func (p RetryPolicy) retryableStatus(status int) bool {
+	for _, candidate := range p.RetryableStatuses {
+		if candidate == status {
+			return true
+		}
+	}
+	return false
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	if p.BaseDelay <= 0 {
+		return 0
+	}
+	delay := p.BaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			delay = p.MaxDelay
+			break
+		}
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		spread := float64(delay) * p.Jitter
+		delay += time.Duration(spread * (2*randFloat() - 1))
+	}
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
+
+var randFloat = rand.Float64
+
+type Option func(*sendOptions)
+
+type sendOptions struct {
+	retry             RetryPolicy
+	componentFallback bool
+}
+
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *sendOptions) {
+		o.retry = policy
+	}
+}
+
+func WithComponentFallback(enabled bool) Option {
+	return func(o *sendOptions) {
+		o.componentFallback = enabled
+	}
+}
+
+func newSendOptions(opts []Option) sendOptions {
+	options := sendOptions{retry: DefaultRetryPolicy()}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&options)
+		}
+	}
+	return options
+}
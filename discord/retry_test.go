@@ -0,0 +1,33 @@
+package discord
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 500 * time.Millisecond}
+
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 500 * time.Millisecond, 500 * time.Millisecond}
+	for i, expected := range want {
+		if got := policy.backoff(i + 1); got != expected {
+			t.Fatalf("attempt %d: expected %s, got %s", i+1, expected, got)
+		}
+	}
+}
+
+func TestRetryPolicyBackoffJitter(t *testing.T) {
+	oldRand := randFloat
+	t.Cleanup(func() { randFloat = oldRand })
+
+	policy := RetryPolicy{BaseDelay: time.Second, Jitter: 0.5}
+
+	randFloat = func() float64 { return 0 }
+	if got := policy.backoff(1); got != 500*time.Millisecond {
+		t.Fatalf("unexpected lower bound: %s", got)
+	}
+	randFloat = func() float64 { return 1 }
+	if got := policy.backoff(1); got != 1500*time.Millisecond {
+		t.Fatalf("unexpected upper bound: %s", got)
+	}
+}
@@ -0,0 +1,25 @@
+package domain
+
+const (
+	AttributeAdapter    = "adapter"
+	AttributeAlarmName  = "alarm_name"
+	AttributeNamespace  = "namespace"
+	AttributeMetricName = "metric_name"
+	AttributeAccount    = "account"
+	AttributeRegion     = "region"
+	AttributeState      = "state"
+	AttributeSource     = "source"
+	AttributeDetailType = "detail_type"
+	AttributeLogGroup   = "log_group"
+	AttributeLocale     = "locale"
+)
+
+func (p NotificationPayload) WithAttribute(name, value string) NotificationPayload {
+	attributes := make(map[string]string, len(p.Attributes)+1)
+	for k, v := range p.Attributes {
+		attributes[k] = v
+	}
+	attributes[name] = value
+	p.Attributes = attributes
+	return p
+}
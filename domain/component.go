@@ -0,0 +1,120 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type ComponentType int
+
+const (
+	ComponentActionRow ComponentType = 1
+	ComponentButton    ComponentType = 2
+)
+
+type ButtonStyle int
+
+const (
+	ButtonPrimary   ButtonStyle = 1
+	ButtonSecondary ButtonStyle = 2
+	ButtonSuccess   ButtonStyle = 3
+	ButtonDanger    ButtonStyle = 4
+	ButtonLink      ButtonStyle = 5
+)
+
+type Component struct {
+	Type       ComponentType `json:"type"`
+	Style      ButtonStyle   `json:"style,omitempty"`
+	Label      string        `json:"label,omitempty"`
+	Emoji      *Emoji        `json:"emoji,omitempty"`
+	CustomID   string        `json:"custom_id,omitempty"`
+	URL        string        `json:"url,omitempty"`
+	Disabled   bool          `json:"disabled,omitempty"`
+	Components []Component   `json:"components,omitempty"`
+}
+
+type Emoji struct {
+	ID       string `json:"id,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Animated bool   `json:"animated,omitempty"`
+}
+
+func ActionRow(components ...Component) Component {
+	return Component{Type: ComponentActionRow, Components: components}
+}
+
+func LinkButton(label, url string) Component {
+	return Component{Type: ComponentButton, Style: ButtonLink, Label: label, URL: url}
+}
+
+func (p NotificationPayload) WithComponentsAsLinks() NotificationPayload {
+	if len(p.Components) == 0 {
+		return p
+	}
+
+	var links []string
+	for _, row := range p.Components {
+		for _, component := range row.Components {
+			if component.Style != ButtonLink || strings.TrimSpace(component.URL) == "" {
+				continue
+			}
+			label := strings.TrimSpace(component.Label)
+			if label == "" {
+				label = component.URL
+			}
+			links = append(links, fmt.Sprintf("[%s](%s)", label, component.URL))
+		}
+	}
+
+	p.Components = nil
+	if len(links) == 0 {
+		return p
+	}
+	joined := strings.Join(links, " · ")
+	if strings.TrimSpace(p.Content) == "" {
+		p.Content = joined
+	} else {
+		p.Content = p.Content + "\n" + joined
+	}
+	return p
+}
+
+func (p NotificationPayload) validateComponents() error {
+	var errs []error
+	if len(p.Components) > MaxActionRows {
+		errs = append(errs, fmt.Errorf("message must have %d or fewer action rows, got %d", MaxActionRows, len(p.Components)))
+	}
+	for i, row := range p.Components {
+		if row.Type != ComponentActionRow {
+			errs = append(errs, fmt.Errorf("components[%d] must be an action row", i))
+			continue
+		}
+		if len(row.Components) == 0 || len(row.Components) > MaxActionRowComponents {
+			errs = append(errs, fmt.Errorf("components[%d] must have between 1 and %d components, got %d", i, MaxActionRowComponents, len(row.Components)))
+		}
+		for j, component := range row.Components {
+			if component.Type != ComponentButton {
+				errs = append(errs, fmt.Errorf("components[%d].components[%d] must be a button", i, j))
+				continue
+			}
+			if strings.TrimSpace(component.Label) == "" && component.Emoji == nil {
+				errs = append(errs, fmt.Errorf("components[%d].components[%d] must have a label or emoji", i, j))
+			}
+			if n := textLength(component.Label); n > MaxButtonLabelLength {
+				errs = append(errs, fmt.Errorf("components[%d].components[%d].label must be %d or fewer characters, got %d", i, j, MaxButtonLabelLength, n))
+			}
+			if component.Style == ButtonLink {
+				if strings.TrimSpace(component.URL) == "" {
+					errs = append(errs, fmt.Errorf("components[%d].components[%d] link button must have a url", i, j))
+				}
+				if n := textLength(component.URL); n > MaxButtonURLLength {
+					errs = append(errs, fmt.Errorf("components[%d].components[%d].url must be %d or fewer characters, got %d", i, j, MaxButtonURLLength, n))
+				}
+			} else if strings.TrimSpace(component.CustomID) == "" {
+				errs = append(errs, fmt.Errorf("components[%d].components[%d] must have a custom_id", i, j))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWithComponentsAsLinks(t *testing.T) {
+	payload := NotificationPayload{
+		Content: "alarm",
+		Components: []Component{
+			ActionRow(LinkButton("Open alarm", "https://example.com/alarm"), LinkButton("", "https://example.com/metric")),
+			ActionRow(Component{Type: ComponentButton, Style: ButtonPrimary, Label: "Ack", CustomID: "ack"}),
+		},
+	}
+
+	rendered := payload.WithComponentsAsLinks()
+	want := "alarm\n[Open alarm](https://example.com/alarm) · [https://example.com/metric](https://example.com/metric)"
+	if rendered.Content != want {
+		t.Fatalf("unexpected content: %q", rendered.Content)
+	}
+	if rendered.Components != nil {
+		t.Fatalf("expected components to be removed: %#v", rendered.Components)
+	}
+	if len(payload.Components) != 2 {
+		t.Fatal("expected original payload to be untouched")
+	}
+}
+
+func TestValidateComponents(t *testing.T) {
+	valid := NotificationPayload{
+		WebhookURL: "https://discord.com/api/webhooks/100/hook",
+		Content:    "alarm",
+		Components: []Component{ActionRow(LinkButton("Open alarm", "https://example.com/alarm"))},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	invalid := valid
+	invalid.Components = []Component{
+		LinkButton("not a row", "https://example.com"),
+		ActionRow(LinkButton("", ""), LinkButton(strings.Repeat("l", MaxButtonLabelLength+1), "https://example.com")),
+	}
+	err := invalid.Validate()
+	if err == nil {
+		t.Fatal("expected component errors")
+	}
+	for _, want := range []string{"components[0] must be an action row", "components[1].components[0] must have a label", "components[1].components[0] link button must have a url", "components[1].components[1].label"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q in error: %v", want, err)
+		}
+	}
+}
+
+func TestSplitKeepsComponentsOnLastPart(t *testing.T) {
+	payload := NotificationPayload{
+		WebhookURL: "https://discord.com/api/webhooks/100/hook",
+		Content:    strings.Repeat("a", MaxContentLength+10),
+		Components: []Component{ActionRow(LinkButton("Open alarm", "https://example.com/alarm"))},
+	}
+
+	parts := payload.Split()
+	if len(parts) != 2 {
+		t.Fatalf("expected two parts, got %d", len(parts))
+	}
+	if parts[0].Components != nil || len(parts[1].Components) != 1 {
+		t.Fatalf("expected components only on the last part: %#v %#v", parts[0].Components, parts[1].Components)
+	}
+}
@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	MaxContentLength               = 2000
+	MaxEmbeds                      = 10
+	MaxEmbedTitleLength            = 256
+	MaxEmbedDescriptionLength      = 4096
+	MaxEmbedFields                 = 25
+	MaxEmbedFieldNameLength        = 256
+	MaxEmbedFieldValueLength       = 1024
+	MaxEmbedFooterLength           = 2048
+	MaxEmbedAuthorNameLength       = 256
+	MaxEmbedsTotalLength           = 6000
+	MaxThreadNameLength            = 100
+	MaxActionRows                  = 5
+	MaxActionRowComponents         = 5
+	MaxButtonLabelLength           = 80
+	MaxButtonURLLength             = 512
+	MaxAttachments                 = 10
+	MaxAttachmentDescriptionLength = 1024
+)
+
+func (e Embed) Length() int {
+	length := textLength(e.Title) + textLength(e.Description)
+	for _, field := range e.Fields {
+		length += field.Length()
+	}
+	if e.Footer != nil {
+		length += textLength(e.Footer.Text)
+	}
+	if e.Author != nil {
+		length += textLength(e.Author.Name)
+	}
+	return length
+}
+
+func (f EmbedField) Length() int {
+	return textLength(f.Name) + textLength(f.Value)
+}
+
+func (p NotificationPayload) validateLimits() error {
+	var errs []error
+	if n := textLength(p.ThreadName); n > MaxThreadNameLength {
+		errs = append(errs, fmt.Errorf("thread name must be %d or fewer characters, got %d", MaxThreadNameLength, n))
+	}
+	if n := textLength(p.Content); n > MaxContentLength {
+		errs = append(errs, fmt.Errorf("content must be %d or fewer characters, got %d", MaxContentLength, n))
+	}
+	if len(p.Attachments) > MaxAttachments {
+		errs = append(errs, fmt.Errorf("message must have %d or fewer attachments, got %d", MaxAttachments, len(p.Attachments)))
+	}
+	for i, attachment := range p.Attachments {
+		if strings.TrimSpace(attachment.Name) == "" {
+			errs = append(errs, fmt.Errorf("attachments[%d] must have a file name", i))
+		}
+		if n := textLength(attachment.Description); n > MaxAttachmentDescriptionLength {
+			errs = append(errs, fmt.Errorf("attachments[%d].description must be %d or fewer characters, got %d", i, MaxAttachmentDescriptionLength, n))
+		}
+	}
+	if len(p.Embeds) > MaxEmbeds {
+		errs = append(errs, fmt.Errorf("message must have %d or fewer embeds, got %d", MaxEmbeds, len(p.Embeds)))
+	}
+
+	total := 0
+	for i, embed := range p.Embeds {
+		total += embed.Length()
+		if n := textLength(embed.Title); n > MaxEmbedTitleLength {
+			errs = append(errs, fmt.Errorf("embeds[%d].title must be %d or fewer characters, got %d", i, MaxEmbedTitleLength, n))
+		}
+		if n := textLength(embed.Description); n > MaxEmbedDescriptionLength {
+			errs = append(errs, fmt.Errorf("embeds[%d].description must be %d or fewer characters, got %d", i, MaxEmbedDescriptionLength, n))
+		}
+		if len(embed.Fields) > MaxEmbedFields {
+			errs = append(errs, fmt.Errorf("embeds[%d] must have %d or fewer fields, got %d", i, MaxEmbedFields, len(embed.Fields)))
+		}
+		for j, field := range embed.Fields {
+			if n := textLength(field.Name); n > MaxEmbedFieldNameLength {
+				errs = append(errs, fmt.Errorf("embeds[%d].fields[%d].name must be %d or fewer characters, got %d", i, j, MaxEmbedFieldNameLength, n))
+			}
+			if n := textLength(field.Value); n > MaxEmbedFieldValueLength {
+				errs = append(errs, fmt.Errorf("embeds[%d].fields[%d].value must be %d or fewer characters, got %d", i, j, MaxEmbedFieldValueLength, n))
+			}
+		}
+		if embed.Footer != nil {
+			if n := textLength(embed.Footer.Text); n > MaxEmbedFooterLength {
+				errs = append(errs, fmt.Errorf("embeds[%d].footer.text must be %d or fewer characters, got %d", i, MaxEmbedFooterLength, n))
+			}
+		}
+		if embed.Author != nil {
+			if n := textLength(embed.Author.Name); n > MaxEmbedAuthorNameLength {
+				errs = append(errs, fmt.Errorf("embeds[%d].author.name must be %d or fewer characters, got %d", i, MaxEmbedAuthorNameLength, n))
+			}
+		}
+	}
+	if total > MaxEmbedsTotalLength {
+		errs = append(errs, fmt.Errorf("embeds must total %d or fewer characters, got %d", MaxEmbedsTotalLength, total))
+	}
+
+	return errors.Join(errs...)
+}
+
+func textLength(text string) int {
+	return utf8.RuneCountInString(text)
+}
@@ -2,25 +2,86 @@ package domain
 
 import (
 	"errors"
+	"fmt"
+	"regexp"
 	"strings"
+	"time"
+)
+
+var snowflakePattern = regexp.MustCompile(`^\d+$`)
+
+type Action string
+
+const (
+	ActionSend   Action = "send"
+	ActionEdit   Action = "edit"
+	ActionDelete Action = "delete"
 )
 
 type NotificationPayload struct {
+	Action          Action
+	MessageID       string
 	WebhookURL      string
+	WebhookURLs     []string
+	Overrides       map[string]DestinationOverride
 	Content         string
 	Embeds          []Embed
 	AllowedMentions *AllowedMentions
 	Username        string
 	AvatarURL       string
+	ThreadID        string
+	ThreadName      string
+	ThreadKey       string
+	Wait            bool
+	Attachments     []Attachment
+	Components      []Component
+	Flags           MessageFlags
+	Correlation     *Correlation
+	Attributes      map[string]string
+	Event           map[string]any
+}
+
+type MessageFlags int
+
+const (
+	FlagSuppressEmbeds        MessageFlags = 1 << 2
+	FlagSuppressNotifications MessageFlags = 1 << 12
+)
+
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+	Description string
+}
+
+type CorrelationKind string
+
+const (
+	CorrelationOpen    CorrelationKind = "open"
+	CorrelationResolve CorrelationKind = "resolve"
+)
+
+type Correlation struct {
+	Key        string
+	Kind       CorrelationKind
+	OccurredAt time.Time
 }
 
 type Embed struct {
-	Title       string       `json:"title,omitempty"`
-	Description string       `json:"description,omitempty"`
-	Color       int          `json:"color,omitempty"`
-	Fields      []EmbedField `json:"fields,omitempty"`
-	Footer      *EmbedFooter `json:"footer,omitempty"`
-	Timestamp   string       `json:"timestamp,omitempty"`
+	Title       string         `json:"title,omitempty"`
+	Type        string         `json:"type,omitempty"`
+	Description string         `json:"description,omitempty"`
+	URL         string         `json:"url,omitempty"`
+	Timestamp   string         `json:"timestamp,omitempty"`
+	Color       int            `json:"color,omitempty"`
+	Footer      *EmbedFooter   `json:"footer,omitempty"`
+	Image       *EmbedMedia    `json:"image,omitempty"`
+	Thumbnail   *EmbedMedia    `json:"thumbnail,omitempty"`
+	Video       *EmbedMedia    `json:"video,omitempty"`
+	Provider    *EmbedProvider `json:"provider,omitempty"`
+	Author      *EmbedAuthor   `json:"author,omitempty"`
+	Fields      []EmbedField   `json:"fields,omitempty"`
 }
 
 type EmbedField struct {
@@ -30,7 +91,28 @@ type EmbedField struct {
 }
 
 type EmbedFooter struct {
-	Text string `json:"text"`
+	Text         string `json:"text"`
+	IconURL      string `json:"icon_url,omitempty"`
+	ProxyIconURL string `json:"proxy_icon_url,omitempty"`
+}
+
+type EmbedMedia struct {
+	URL      string `json:"url"`
+	ProxyURL string `json:"proxy_url,omitempty"`
+	Height   int    `json:"height,omitempty"`
+	Width    int    `json:"width,omitempty"`
+}
+
+type EmbedProvider struct {
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url,omitempty"`
+}
+
+type EmbedAuthor struct {
+	Name         string `json:"name"`
+	URL          string `json:"url,omitempty"`
+	IconURL      string `json:"icon_url,omitempty"`
+	ProxyIconURL string `json:"proxy_icon_url,omitempty"`
 }
 
 type AllowedMentions struct {
@@ -55,8 +137,35 @@ func (p NotificationPayload) Validate() error {
 	if strings.TrimSpace(p.WebhookURL) == "" {
 		return errors.New("discord webhook URL must be provided")
 	}
-	if strings.TrimSpace(p.Content) == "" && len(p.Embeds) == 0 {
-		return errors.New("notification payload must include content or embeds")
+	if _, err := ParseWebhookURL(p.WebhookURL); err != nil {
+		return err
+	}
+	switch p.Action {
+	case "", ActionSend:
+	case ActionEdit, ActionDelete:
+		if strings.TrimSpace(p.MessageID) == "" {
+			return fmt.Errorf("%s action requires a message id", p.Action)
+		}
+		if !snowflakePattern.MatchString(p.MessageID) {
+			return fmt.Errorf("invalid message id %q: must be a numeric Discord snowflake", p.MessageID)
+		}
+		if p.Action == ActionDelete {
+			return nil
+		}
+	default:
+		return fmt.Errorf("unsupported action: %s", p.Action)
+	}
+	if strings.TrimSpace(p.Content) == "" && len(p.Embeds) == 0 && len(p.Attachments) == 0 {
+		return errors.New("notification payload must include content, embeds or attachments")
+	}
+	if strings.TrimSpace(p.ThreadID) != "" && strings.TrimSpace(p.ThreadName) != "" {
+		return errors.New("thread id and thread name cannot both be set")
+	}
+	if unsupported := p.Flags &^ (FlagSuppressEmbeds | FlagSuppressNotifications); unsupported != 0 {
+		return fmt.Errorf("unsupported message flags: %d", unsupported)
+	}
+	if err := p.validateComponents(); err != nil {
+		return err
 	}
-	return nil
+	return p.validateLimits()
 }
@@ -0,0 +1,223 @@
+package domain
+
+import "strings"
+
+const (
+	codeFence = "```"
+	ellipsis  = "…"
+)
+
+func (p NotificationPayload) Split() []NotificationPayload {
+	if p.validateLimits() == nil {
+		return []NotificationPayload{p}
+	}
+	p.ThreadName = truncateText(p.ThreadName, MaxThreadNameLength)
+
+	var parts []NotificationPayload
+	for _, chunk := range splitText(p.Content, MaxContentLength) {
+		parts = append(parts, p.withBody(chunk, nil))
+	}
+
+	var embeds []Embed
+	for _, embed := range p.Embeds {
+		embeds = append(embeds, splitEmbed(embed)...)
+	}
+	for i, group := range groupEmbeds(embeds) {
+		if i == 0 && len(parts) > 0 {
+			parts[len(parts)-1].Embeds = group
+			continue
+		}
+		parts = append(parts, p.withBody("", group))
+	}
+
+	if len(parts) == 0 {
+		return []NotificationPayload{p}
+	}
+	for i := range parts {
+		if i > 0 {
+			parts[i].Attachments = nil
+		}
+		if i < len(parts)-1 {
+			parts[i].Components = nil
+		}
+	}
+	return parts
+}
+
+func (p NotificationPayload) withBody(content string, embeds []Embed) NotificationPayload {
+	p.Content = content
+	p.Embeds = embeds
+	return p
+}
+
+func splitEmbed(e Embed) []Embed {
+	if len(e.Fields) <= MaxEmbedFields && (NotificationPayload{Embeds: []Embed{e}}).validateLimits() == nil {
+		return []Embed{e}
+	}
+
+	fields := make([]EmbedField, len(e.Fields))
+	for i, field := range e.Fields {
+		field.Name = truncateText(field.Name, MaxEmbedFieldNameLength)
+		field.Value = truncateText(field.Value, MaxEmbedFieldValueLength)
+		fields[i] = field
+	}
+	footer := e.Footer
+	timestamp := e.Timestamp
+
+	head := e
+	head.Title = truncateText(e.Title, MaxEmbedTitleLength)
+	if e.Author != nil {
+		author := *e.Author
+		author.Name = truncateText(author.Name, MaxEmbedAuthorNameLength)
+		head.Author = &author
+	}
+	head.Fields = nil
+	head.Footer = nil
+	head.Timestamp = ""
+
+	descriptions := splitText(e.Description, MaxEmbedDescriptionLength)
+	if len(descriptions) > 0 {
+		head.Description = descriptions[0]
+	}
+	embeds := []Embed{head}
+	for _, description := range descriptions[min(1, len(descriptions)):] {
+		embeds = append(embeds, Embed{Description: description, Color: e.Color})
+	}
+
+	for _, field := range fields {
+		last := len(embeds) - 1
+		if len(embeds[last].Fields) == MaxEmbedFields || embeds[last].Length()+field.Length() > MaxEmbedsTotalLength {
+			embeds = append(embeds, Embed{Color: e.Color})
+			last++
+		}
+		embeds[last].Fields = append(embeds[last].Fields, field)
+	}
+
+	last := len(embeds) - 1
+	embeds[last].Timestamp = timestamp
+	if footer != nil {
+		limit := min(MaxEmbedFooterLength, MaxEmbedsTotalLength-embeds[last].Length())
+		if limit > 0 {
+			trimmed := *footer
+			trimmed.Text = truncateText(footer.Text, limit)
+			embeds[last].Footer = &trimmed
+		}
+	}
+
+	return embeds
+}
+
+func groupEmbeds(embeds []Embed) [][]Embed {
+	var groups [][]Embed
+	var current []Embed
+	total := 0
+	for _, embed := range embeds {
+		length := embed.Length()
+		if len(current) == MaxEmbeds || (len(current) > 0 && total+length > MaxEmbedsTotalLength) {
+			groups = append(groups, current)
+			current = nil
+			total = 0
+		}
+		current = append(current, embed)
+		total += length
+	}
+	if len(current) > 0 {
+		groups = append(groups, current)
+	}
+	return groups
+}
+
+func splitText(text string, limit int) []string {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	if textLength(text) <= limit {
+		return []string{text}
+	}
+
+	closing := "\n" + codeFence
+	var chunks []string
+	openFence := ""
+	remaining := text
+	for remaining != "" {
+		prefix := ""
+		if openFence != "" {
+			prefix = openFence + "\n"
+		}
+		budget := limit - textLength(prefix)
+		if textLength(remaining) <= budget {
+			chunks = append(chunks, prefix+remaining)
+			break
+		}
+
+		cut := cutIndex(remaining, budget-textLength(closing))
+		chunk := remaining[:cut]
+		remaining = strings.TrimPrefix(remaining[cut:], "\n")
+
+		openFence = fenceAfter(openFence, chunk)
+		chunk = prefix + chunk
+		if openFence != "" {
+			chunk += closing
+		}
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+func truncateText(text string, limit int) string {
+	if textLength(text) <= limit {
+		return text
+	}
+	cut := text[:cutIndex(text, limit-textLength(ellipsis))]
+	if fenceAfter("", cut) == "" {
+		return cut + ellipsis
+	}
+	closing := "\n" + codeFence
+	cut = text[:cutIndex(text, limit-textLength(ellipsis)-textLength(closing))]
+	if fenceAfter("", cut) == "" {
+		return cut + ellipsis
+	}
+	return cut + ellipsis + closing
+}
+
+func cutIndex(text string, limit int) int {
+	if limit < 1 {
+		limit = 1
+	}
+	end := len(text)
+	count := 0
+	for i := range text {
+		if count == limit {
+			end = i
+			break
+		}
+		count++
+	}
+	if end == len(text) {
+		return end
+	}
+
+	window := text[:end]
+	if i := strings.LastIndex(window, "\n"); i > 0 {
+		return i
+	}
+	if i := strings.LastIndex(window, " "); i > 0 {
+		return i + 1
+	}
+	return end
+}
+
+func fenceAfter(open string, text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, codeFence) {
+			continue
+		}
+		if open == "" {
+			open = trimmed
+		} else {
+			open = ""
+		}
+	}
+	return open
+}
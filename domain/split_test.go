@@ -0,0 +1,187 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateLimits(t *testing.T) {
+	payload := NotificationPayload{
+		WebhookURL: "https://discord.com/api/webhooks/100/hook",
+		Content:    strings.Repeat("a", MaxContentLength+1),
+		Embeds: []Embed{{
+			Title:       strings.Repeat("t", MaxEmbedTitleLength+1),
+			Description: strings.Repeat("d", MaxEmbedDescriptionLength+1),
+			Fields:      []EmbedField{{Name: "name", Value: strings.Repeat("v", MaxEmbedFieldValueLength+1)}},
+		}},
+	}
+
+	err := payload.Validate()
+	if err == nil {
+		t.Fatal("expected limit errors")
+	}
+	for _, want := range []string{"content", "embeds[0].title", "embeds[0].description", "embeds[0].fields[0].value"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q in error: %v", want, err)
+		}
+	}
+}
+
+func TestEmbedLengthCountsAuthor(t *testing.T) {
+	embed := Embed{Title: "abc", Author: &EmbedAuthor{Name: "author", IconURL: "https://example.com/icon.png"}}
+	if got := embed.Length(); got != 9 {
+		t.Fatalf("expected author name to be counted, got %d", got)
+	}
+
+	embed.Author.Name = strings.Repeat("a", MaxEmbedAuthorNameLength+1)
+	err := NotificationPayload{WebhookURL: "https://discord.com/api/webhooks/100/hook", Embeds: []Embed{embed}}.Validate()
+	if err == nil || !strings.Contains(err.Error(), "embeds[0].author.name") {
+		t.Fatalf("expected author name limit error: %v", err)
+	}
+}
+
+func TestValidateFlags(t *testing.T) {
+	payload := NotificationPayload{
+		WebhookURL: "https://discord.com/api/webhooks/100/hook",
+		Content:    "hello",
+		Flags:      FlagSuppressEmbeds | FlagSuppressNotifications,
+	}
+	if err := payload.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	payload.Flags |= 1 << 6
+	if err := payload.Validate(); err == nil || !strings.Contains(err.Error(), "unsupported message flags: 64") {
+		t.Fatalf("expected unsupported flag error: %v", err)
+	}
+}
+
+func TestValidateAttachments(t *testing.T) {
+	payload := NotificationPayload{
+		WebhookURL:  "https://discord.com/api/webhooks/100/hook",
+		Attachments: []Attachment{{Name: "request.json", Data: []byte("{}")}},
+	}
+	if err := payload.Validate(); err != nil {
+		t.Fatalf("expected attachment-only payload to be valid: %v", err)
+	}
+
+	payload.Attachments = append(payload.Attachments, Attachment{Description: strings.Repeat("d", MaxAttachmentDescriptionLength+1)})
+	err := payload.Validate()
+	if err == nil {
+		t.Fatal("expected attachment errors")
+	}
+	for _, want := range []string{"attachments[1] must have a file name", "attachments[1].description"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q in error: %v", want, err)
+		}
+	}
+}
+
+func TestSplitKeepsPayloadWithinLimits(t *testing.T) {
+	payload := NotificationPayload{WebhookURL: "https://discord.com/api/webhooks/100/hook", Content: "hello", Username: "bot"}
+
+	parts := payload.Split()
+	if len(parts) != 1 || parts[0].Content != "hello" {
+		t.Fatalf("expected payload to be untouched: %#v", parts)
+	}
+}
+
+func TestSplitLongContentPreservesCodeFences(t *testing.T) {
+	lines := make([]string, 0, 400)
+	for i := 0; i < 400; i++ {
+		lines = append(lines, "line of log output")
+	}
+	content := "summary\n```json\n" + strings.Join(lines, "\n") + "\n```"
+	payload := NotificationPayload{WebhookURL: "https://discord.com/api/webhooks/100/hook", Content: content, Username: "bot"}
+
+	parts := payload.Split()
+	if len(parts) < 2 {
+		t.Fatalf("expected content to be split, got %d parts", len(parts))
+	}
+	for i, part := range parts {
+		if err := part.Validate(); err != nil {
+			t.Fatalf("part %d is invalid: %v", i, err)
+		}
+		if part.Username != "bot" || part.WebhookURL != payload.WebhookURL {
+			t.Fatalf("part %d lost payload metadata: %#v", i, part)
+		}
+		if strings.Count(part.Content, codeFence)%2 != 0 {
+			t.Fatalf("part %d has an unbalanced code fence:\n%s", i, part.Content)
+		}
+		if i > 0 && !strings.HasPrefix(part.Content, "```json\n") {
+			t.Fatalf("part %d should reopen the json fence:\n%s", i, part.Content[:20])
+		}
+	}
+}
+
+func TestSplitOversizedEmbeds(t *testing.T) {
+	fields := make([]EmbedField, 30)
+	for i := range fields {
+		fields[i] = EmbedField{Name: "field", Value: strings.Repeat("v", 1100)}
+	}
+	payload := NotificationPayload{
+		WebhookURL: "https://discord.com/api/webhooks/100/hook",
+		Content:    "alert",
+		Embeds: []Embed{{
+			Title:       "Request",
+			Description: "```json\n" + strings.Repeat("{\"key\": \"value\"}\n", 400) + "```",
+			Color:       0xE74C3C,
+			Fields:      fields,
+			Footer:      &EmbedFooter{Text: "footer"},
+			Timestamp:   "2024-01-02T03:04:05Z",
+		}},
+	}
+
+	parts := payload.Split()
+	if len(parts) < 2 {
+		t.Fatalf("expected embeds to be split across messages, got %d", len(parts))
+	}
+	if parts[0].Content != "alert" {
+		t.Fatalf("expected content on the first message: %q", parts[0].Content)
+	}
+
+	var embeds []Embed
+	for i, part := range parts {
+		if err := part.Validate(); err != nil {
+			t.Fatalf("part %d is invalid: %v", i, err)
+		}
+		embeds = append(embeds, part.Embeds...)
+	}
+	if embeds[0].Title != "Request" {
+		t.Fatalf("expected title on the first embed: %#v", embeds[0])
+	}
+	last := embeds[len(embeds)-1]
+	if last.Footer == nil || last.Footer.Text != "footer" || last.Timestamp == "" {
+		t.Fatalf("expected footer and timestamp on the last embed: %#v", last)
+	}
+
+	total := 0
+	for _, embed := range embeds {
+		if embed.Color != 0xE74C3C {
+			t.Fatalf("expected continuation embeds to keep the color: %#v", embed)
+		}
+		if strings.Count(embed.Description, codeFence)%2 != 0 {
+			t.Fatalf("unbalanced code fence in description: %s", embed.Description)
+		}
+		for _, field := range embed.Fields {
+			if !strings.HasSuffix(field.Value, ellipsis) {
+				t.Fatalf("expected field value to be truncated: %d", len(field.Value))
+			}
+		}
+		total += len(embed.Fields)
+	}
+	if total != len(fields) {
+		t.Fatalf("expected all %d fields to be kept, got %d", len(fields), total)
+	}
+}
+
+func TestTruncateTextClosesCodeFence(t *testing.T) {
+	text := "```\n" + strings.Repeat("x", 50) + "\n```"
+	got := truncateText(text, 20)
+	if textLength(got) > 20 {
+		t.Fatalf("truncated text too long: %d", textLength(got))
+	}
+	if !strings.HasSuffix(got, ellipsis+"\n```") {
+		t.Fatalf("expected closing fence: %q", got)
+	}
+}
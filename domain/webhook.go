@@ -0,0 +1,168 @@
+package domain
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"lambda-to-discord/redact"
+)
+
+var (
+	webhookHosts = map[string]bool{
+		"discord.com":           true,
+		"ptb.discord.com":       true,
+		"canary.discord.com":    true,
+		"discordapp.com":        true,
+		"ptb.discordapp.com":    true,
+		"canary.discordapp.com": true,
+	}
+	webhookPathPattern = regexp.MustCompile(`^/api(?:/v\d+)?/webhooks/(\d+)/([A-Za-z0-9_-]+)/?$`)
+)
+
+type WebhookURL struct {
+	Host  string
+	ID    string
+	Token string
+}
+
+func ParseWebhookURL(raw string) (WebhookURL, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return WebhookURL{}, redact.Error(fmt.Errorf("invalid webhook URL: %w", err))
+	}
+	if u.Scheme != "https" {
+		return WebhookURL{}, fmt.Errorf("invalid webhook URL: scheme must be https, got %q", u.Scheme)
+	}
+	if u.User != nil || u.Port() != "" && u.Port() != "443" {
+		return WebhookURL{}, fmt.Errorf("invalid webhook URL: unexpected credentials or port")
+	}
+	host := strings.ToLower(u.Hostname())
+	if !webhookHosts[host] {
+		return WebhookURL{}, fmt.Errorf("invalid webhook URL: host %q is not a Discord host", host)
+	}
+	match := webhookPathPattern.FindStringSubmatch(u.EscapedPath())
+	if match == nil {
+		return WebhookURL{}, fmt.Errorf("invalid webhook URL: path must be /api/webhooks/{id}/{token}")
+	}
+	return WebhookURL{Host: host, ID: match[1], Token: match[2]}, nil
+}
+
+type WebhookPolicy struct {
+	AllowedHosts []string
+	AllowedIDs   []string
+}
+
+func (p WebhookPolicy) Check(raw string) error {
+	webhook, err := ParseWebhookURL(raw)
+	if err != nil {
+		return err
+	}
+	if len(p.AllowedHosts) > 0 && !containsFold(p.AllowedHosts, webhook.Host) {
+		return fmt.Errorf("webhook host %q is not in the allowed hosts", webhook.Host)
+	}
+	if len(p.AllowedIDs) > 0 && !containsFold(p.AllowedIDs, webhook.ID) {
+		return fmt.Errorf("webhook id %s is not in the allowed webhook ids", webhook.ID)
+	}
+	return nil
+}
+
+func (p NotificationPayload) Destinations() []string {
+	seen := map[string]bool{}
+	var destinations []string
+	for _, raw := range append([]string{p.WebhookURL}, p.WebhookURLs...) {
+		if raw = strings.TrimSpace(raw); raw != "" && !seen[raw] {
+			seen[raw] = true
+			destinations = append(destinations, raw)
+		}
+	}
+	return destinations
+}
+
+type DestinationOverride struct {
+	Username     string
+	AvatarURL    string
+	MentionRoles []string
+}
+
+func (o DestinationOverride) Merge(other DestinationOverride) DestinationOverride {
+	if o.Username == "" {
+		o.Username = other.Username
+	}
+	if o.AvatarURL == "" {
+		o.AvatarURL = other.AvatarURL
+	}
+	o.MentionRoles = append(append([]string(nil), o.MentionRoles...), other.MentionRoles...)
+	return o
+}
+
+func (p NotificationPayload) ForDestination(webhookURL string) NotificationPayload {
+	fanOut := len(p.Destinations()) > 1
+	override := p.Overrides[webhookURL]
+	p.WebhookURL = webhookURL
+	p.WebhookURLs = nil
+	p.Overrides = nil
+	if override.Username != "" {
+		p.Username = override.Username
+	}
+	if override.AvatarURL != "" {
+		p.AvatarURL = override.AvatarURL
+	}
+	p = p.WithRoleMentions(override.MentionRoles)
+	if fanOut {
+		p = p.WithCorrelationScope(webhookURL)
+	}
+	return p
+}
+
+func (p NotificationPayload) WithRoleMentions(roles []string) NotificationPayload {
+	seen := map[string]bool{}
+	var mentions, allowed []string
+	for _, role := range roles {
+		if role = strings.TrimSpace(role); role != "" && !seen[role] {
+			seen[role] = true
+			mentions = append(mentions, fmt.Sprintf("<@&%s>", role))
+			allowed = append(allowed, role)
+		}
+	}
+	if len(mentions) == 0 {
+		return p
+	}
+	p.Content = strings.TrimSpace(strings.Join(mentions, " ") + " " + p.Content)
+
+	current := p.AllowedMentions
+	if current == nil || containsFold(current.Parse, "roles") {
+		return p
+	}
+	p.AllowedMentions = &AllowedMentions{
+		Parse:       append([]string{}, current.Parse...),
+		Users:       current.Users,
+		Roles:       append(append([]string(nil), current.Roles...), allowed...),
+		RepliedUser: current.RepliedUser,
+	}
+	return p
+}
+
+func (p NotificationPayload) WithCorrelationScope(webhookURL string) NotificationPayload {
+	if p.Correlation == nil {
+		return p
+	}
+	scope := webhookURL
+	if webhook, err := ParseWebhookURL(webhookURL); err == nil {
+		scope = webhook.ID
+	}
+	correlation := *p.Correlation
+	correlation.Key = correlation.Key + "|" + scope
+	p.Correlation = &correlation
+	return p
+}
+
+func containsFold(values []string, target string) bool {
+	for _, value := range values {
+		if strings.EqualFold(strings.TrimSpace(value), target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseWebhookURL(t *testing.T) {
+	valid := []struct {
+		raw  string
+		host string
+	}{
+		{"https://discord.com/api/webhooks/123/abc-DEF_1", "discord.com"},
+		{"https://discordapp.com/api/webhooks/123/abc", "discordapp.com"},
+		{"https://ptb.discord.com/api/v10/webhooks/123/abc/", "ptb.discord.com"},
+		{"https://Canary.Discord.com/api/webhooks/123/abc?thread_id=1", "canary.discord.com"},
+	}
+	for _, tt := range valid {
+		webhook, err := ParseWebhookURL(tt.raw)
+		if err != nil {
+			t.Fatalf("expected %s to be valid: %v", tt.raw, err)
+		}
+		if webhook.Host != tt.host || webhook.ID != "123" || webhook.Token == "" {
+			t.Fatalf("unexpected webhook for %s: %#v", tt.raw, webhook)
+		}
+	}
+
+	invalid := map[string]string{
+		"http://discord.com/api/webhooks/123/abc":           "https",
+		"https://example.com/api/webhooks/123/abc":          "not a Discord host",
+		"https://discord.com.evil.example/api/webhooks/1/a": "not a Discord host",
+		"https://user@discord.com/api/webhooks/123/abc":     "credentials",
+		"https://discord.com:8443/api/webhooks/123/abc":     "port",
+		"https://discord.com/api/webhooks/abc/abc":          "path",
+		"https://discord.com/api/webhooks/123":              "path",
+		"https://discord.com/api/webhooks/123/abc/github":   "path",
+		"https://discord.com/api/channels/123/messages":     "path",
+	}
+	for raw, want := range invalid {
+		if _, err := ParseWebhookURL(raw); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %s to be rejected with %q: %v", raw, want, err)
+		}
+	}
+}
+
+func TestWebhookPolicyCheck(t *testing.T) {
+	raw := "https://discord.com/api/webhooks/123/abc"
+
+	if err := (WebhookPolicy{}).Check(raw); err != nil {
+		t.Fatalf("expected empty policy to allow any Discord webhook: %v", err)
+	}
+	if err := (WebhookPolicy{}).Check("https://example.com/hook"); err == nil {
+		t.Fatal("expected empty policy to still reject non-Discord URLs")
+	}
+	if err := (WebhookPolicy{AllowedHosts: []string{"Discord.com"}, AllowedIDs: []string{"123"}}).Check(raw); err != nil {
+		t.Fatalf("expected allowed webhook: %v", err)
+	}
+	if err := (WebhookPolicy{AllowedHosts: []string{"canary.discord.com"}}).Check(raw); err == nil || !strings.Contains(err.Error(), "allowed hosts") {
+		t.Fatalf("expected host to be rejected: %v", err)
+	}
+	if err := (WebhookPolicy{AllowedIDs: []string{"456"}}).Check(raw); err == nil || !strings.Contains(err.Error(), "allowed webhook ids") {
+		t.Fatalf("expected id to be rejected: %v", err)
+	}
+}
+
+func TestDestinations(t *testing.T) {
+	payload := NotificationPayload{
+		WebhookURL:  "https://discord.com/api/webhooks/1/team",
+		WebhookURLs: []string{"https://discord.com/api/webhooks/2/incidents", " ", "https://discord.com/api/webhooks/1/team"},
+		Correlation: &Correlation{Key: "alarm"},
+	}
+	destinations := payload.Destinations()
+	if len(destinations) != 2 || destinations[0] != payload.WebhookURL {
+		t.Fatalf("expected deduplicated destinations: %#v", destinations)
+	}
+
+	single := payload.ForDestination(destinations[1])
+	if single.WebhookURL != destinations[1] || single.WebhookURLs != nil {
+		t.Fatalf("unexpected destination payload: %#v", single)
+	}
+	if single.Correlation.Key != "alarm|2" || payload.Correlation.Key != "alarm" {
+		t.Fatalf("expected correlation to be scoped per webhook: %s", single.Correlation.Key)
+	}
+
+	only := NotificationPayload{WebhookURL: "https://discord.com/api/webhooks/1/team", Correlation: &Correlation{Key: "alarm"}}
+	if got := only.ForDestination(only.WebhookURL).Correlation.Key; got != "alarm" {
+		t.Fatalf("expected single destination to keep its correlation key: %s", got)
+	}
+}
+
+func TestForDestinationAppliesOverrides(t *testing.T) {
+	payload := NotificationPayload{
+		WebhookURL:      "https://discord.com/api/webhooks/1/team",
+		WebhookURLs:     []string{"https://discord.com/api/webhooks/2/incidents"},
+		Username:        "Alerts",
+		Content:         "CPUHigh",
+		AllowedMentions: NoMentions(),
+		Overrides: map[string]DestinationOverride{
+			"https://discord.com/api/webhooks/1/team": DestinationOverride{Username: "Team"}.Merge(DestinationOverride{Username: "Other", MentionRoles: []string{"111", " ", "111"}}),
+		},
+	}
+
+	team := payload.ForDestination("https://discord.com/api/webhooks/1/team")
+	if team.Username != "Team" || team.Content != "<@&111> CPUHigh" || team.Overrides != nil {
+		t.Fatalf("expected team override to be applied: %#v", team)
+	}
+	if len(team.AllowedMentions.Roles) != 1 || team.AllowedMentions.Roles[0] != "111" {
+		t.Fatalf("expected mentioned role to be allowed: %#v", team.AllowedMentions)
+	}
+
+	incidents := payload.ForDestination("https://discord.com/api/webhooks/2/incidents")
+	if incidents.Username != "Alerts" || incidents.Content != "CPUHigh" || len(incidents.AllowedMentions.Roles) != 0 {
+		t.Fatalf("expected destination without override to be untouched: %#v", incidents)
+	}
+}
@@ -0,0 +1,293 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+
+	"lambda-to-discord/discord"
+	"lambda-to-discord/domain"
+	"lambda-to-discord/i18n"
+	"lambda-to-discord/redact"
+	"lambda-to-discord/state"
+)
+
+const (
+	stateStoreEnvVar       = "STATE_STORE"
+	stateFilePathEnvVar    = "STATE_FILE_PATH"
+	stateTableEnvVar       = "STATE_TABLE_NAME"
+	alarmResolveModeEnvVar = "ALARM_RESOLVE_MODE"
+
+	defaultStateFilePath = "/tmp/lambda-to-discord-state.json"
+)
+
+const (
+	resolveModeEdit  = "edit"
+	resolveModeReply = "reply"
+)
+
+var alarmStateStore state.Store
+
+func loadStateStore(ctx context.Context) (state.Store, error) {
+	if alarmStateStore != nil {
+		return alarmStateStore, nil
+	}
+
+	switch kind := strings.ToLower(strings.TrimSpace(os.Getenv(stateStoreEnvVar))); kind {
+	case "":
+		return nil, nil
+	case "memory":
+		alarmStateStore = state.NewMemoryStore()
+	case "file":
+		path := strings.TrimSpace(os.Getenv(stateFilePathEnvVar))
+		if path == "" {
+			path = defaultStateFilePath
+		}
+		alarmStateStore = state.NewFileStore(path)
+	case "dynamodb":
+		table := strings.TrimSpace(os.Getenv(stateTableEnvVar))
+		if table == "" {
+			return nil, fmt.Errorf("%s=dynamodb requires %s", stateStoreEnvVar, stateTableEnvVar)
+		}
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load AWS config for DynamoDB: %w", err)
+		}
+		alarmStateStore = state.NewDynamoDBStore(dynamodb.NewFromConfig(cfg), table)
+	default:
+		return nil, fmt.Errorf("unsupported %s: %s", stateStoreEnvVar, kind)
+	}
+	return alarmStateStore, nil
+}
+
+func loadResolveMode() (string, error) {
+	switch mode := strings.ToLower(strings.TrimSpace(os.Getenv(alarmResolveModeEnvVar))); mode {
+	case "", resolveModeEdit:
+		return resolveModeEdit, nil
+	case resolveModeReply:
+		return resolveModeReply, nil
+	default:
+		return "", fmt.Errorf("unsupported %s: %s", alarmResolveModeEnvVar, mode)
+	}
+}
+
+type alarmTracker struct {
+	store       state.Store
+	resolveMode string
+}
+
+func (t alarmTracker) deliver(ctx context.Context, client discord.HTTPClient, payload domain.NotificationPayload, opts ...discord.Option) (discord.Result, error) {
+	if t.store == nil || payload.Action != "" && payload.Action != domain.ActionSend {
+		return dispatch(ctx, client, payload, opts...)
+	}
+	key := threadKey(payload)
+	if key == "" {
+		return t.track(ctx, client, payload, opts...)
+	}
+
+	thread, ok, err := t.store.Get(ctx, key)
+	if err != nil {
+		return discord.Result{}, fmt.Errorf("failed to load alarm thread: %w", err)
+	}
+	if ok && thread.ThreadID != "" {
+		inThread := payload
+		inThread.ThreadID = thread.ThreadID
+		inThread.ThreadName = ""
+		result, err := t.track(ctx, client, inThread, opts...)
+		var apiErr *discord.APIError
+		if !errors.As(err, &apiErr) || apiErr.Code != discord.CodeUnknownChannel {
+			return result, err
+		}
+	}
+
+	payload.Wait = true
+	result, err := t.track(ctx, client, payload, opts...)
+	if err != nil || len(result.Messages) == 0 {
+		return result, err
+	}
+	thread = state.Record{
+		MessageID: result.Messages[0].ID,
+		ChannelID: result.Messages[0].ChannelID,
+		ThreadID:  result.Messages[0].ChannelID,
+		WebhookID: webhookID(payload.WebhookURL),
+	}
+	if err := t.store.Put(ctx, key, thread); err != nil {
+		return result, fmt.Errorf("failed to store alarm thread: %w", err)
+	}
+	return result, nil
+}
+
+func threadKey(payload domain.NotificationPayload) string {
+	if payload.ThreadKey == "" || payload.ThreadName == "" || payload.ThreadID != "" {
+		return ""
+	}
+	return "thread|" + webhookID(payload.WebhookURL) + "|" + payload.ThreadKey
+}
+
+func webhookID(webhookURL string) string {
+	if webhook, err := domain.ParseWebhookURL(webhookURL); err == nil {
+		return webhook.ID
+	}
+	return redact.String(webhookURL)
+}
+
+func (t alarmTracker) track(ctx context.Context, client discord.HTTPClient, payload domain.NotificationPayload, opts ...discord.Option) (discord.Result, error) {
+	correlation := payload.Correlation
+	if correlation == nil || correlation.Key == "" {
+		return dispatch(ctx, client, payload, opts...)
+	}
+
+	switch correlation.Kind {
+	case domain.CorrelationOpen:
+		return t.open(ctx, client, payload, opts...)
+	case domain.CorrelationResolve:
+		return t.resolve(ctx, client, payload, opts...)
+	}
+	return dispatch(ctx, client, payload, opts...)
+}
+
+func (t alarmTracker) open(ctx context.Context, client discord.HTTPClient, payload domain.NotificationPayload, opts ...discord.Option) (discord.Result, error) {
+	previous, ok, err := t.store.Get(ctx, payload.Correlation.Key)
+	if err != nil {
+		return discord.Result{}, fmt.Errorf("failed to load alarm message: %w", err)
+	}
+	if ok && previous.ResolvedAt.IsZero() && previous.WebhookID == webhookID(payload.WebhookURL) && sameEvent(previous.StartedAt, payload.Correlation) {
+		return storedResult(previous), nil
+	}
+
+	payload.Wait = true
+	result, err := dispatch(ctx, client, payload, opts...)
+	if err != nil || len(result.Messages) == 0 {
+		return result, err
+	}
+
+	message := result.Messages[0]
+	record := state.Record{
+		MessageID: message.ID,
+		ChannelID: message.ChannelID,
+		ThreadID:  payload.ThreadID,
+		WebhookID: webhookID(payload.WebhookURL),
+		StartedAt: occurredAt(payload.Correlation),
+	}
+	if record.ThreadID == "" && payload.ThreadName != "" {
+		record.ThreadID = message.ChannelID
+	}
+	if err := t.store.Put(ctx, payload.Correlation.Key, record); err != nil {
+		return result, fmt.Errorf("failed to store alarm message: %w", err)
+	}
+	return result, nil
+}
+
+func (t alarmTracker) resolve(ctx context.Context, client discord.HTTPClient, payload domain.NotificationPayload, opts ...discord.Option) (discord.Result, error) {
+	key := payload.Correlation.Key
+	record, ok, err := t.store.Get(ctx, key)
+	if err != nil {
+		return discord.Result{}, fmt.Errorf("failed to load alarm message: %w", err)
+	}
+	if ok && !record.ResolvedAt.IsZero() {
+		if sameEvent(record.ResolvedAt, payload.Correlation) {
+			return storedResult(record), nil
+		}
+		record, ok = state.Record{}, false
+	}
+
+	payload = withResolvedField(payload, record.StartedAt, occurredAt(payload.Correlation))
+
+	var result discord.Result
+	switch {
+	case !ok || record.WebhookID != webhookID(payload.WebhookURL):
+		record = state.Record{WebhookID: webhookID(payload.WebhookURL)}
+		result, err = dispatch(ctx, client, payload, opts...)
+	case t.resolveMode == resolveModeReply:
+		if record.ThreadID != "" {
+			payload.ThreadID = record.ThreadID
+			payload.ThreadName = ""
+		}
+		result, err = dispatch(ctx, client, payload, opts...)
+	default:
+		fallback := payload
+		payload.ThreadID = record.ThreadID
+		payload.ThreadName = ""
+		result, err = discord.Edit(ctx, client, payload.WebhookURL, record.MessageID, payload, opts...)
+
+		var apiErr *discord.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			if apiErr.Code != discord.CodeUnknownChannel && record.ThreadID != "" {
+				fallback.ThreadID = record.ThreadID
+				fallback.ThreadName = ""
+			}
+			result, err = dispatch(ctx, client, fallback, opts...)
+		}
+	}
+	if err != nil {
+		return result, err
+	}
+
+	record.ResolvedAt = occurredAt(payload.Correlation)
+	if err := t.store.Put(ctx, key, record); err != nil {
+		return result, fmt.Errorf("failed to mark alarm message resolved: %w", err)
+	}
+	return result, nil
+}
+
+func sameEvent(at time.Time, correlation *domain.Correlation) bool {
+	return !at.IsZero() && at.Equal(correlation.OccurredAt)
+}
+
+func storedResult(record state.Record) discord.Result {
+	result := discord.Result{StatusCode: http.StatusOK}
+	if record.MessageID != "" {
+		result.Messages = []discord.Message{{ID: record.MessageID, ChannelID: record.ChannelID}}
+	}
+	return result
+}
+
+func occurredAt(correlation *domain.Correlation) time.Time {
+	if !correlation.OccurredAt.IsZero() {
+		return correlation.OccurredAt
+	}
+	return time.Now()
+}
+
+func withResolvedField(payload domain.NotificationPayload, startedAt, resolvedAt time.Time) domain.NotificationPayload {
+	if startedAt.IsZero() || len(payload.Embeds) == 0 {
+		return payload
+	}
+
+	catalog, err := i18n.Lookup(payload.Attributes[domain.AttributeLocale])
+	if err != nil {
+		catalog = i18n.Default()
+	}
+	embeds := append([]domain.Embed(nil), payload.Embeds...)
+	embeds[0].Fields = append(append([]domain.EmbedField(nil), embeds[0].Fields...), domain.EmbedField{
+		Name:  catalog.T(i18n.FieldResolvedAfter),
+		Value: formatElapsed(resolvedAt.Sub(startedAt), catalog),
+	})
+	payload.Embeds = embeds
+	return payload
+}
+
+func formatElapsed(d time.Duration, catalog i18n.Catalog) string {
+	d = d.Round(time.Minute)
+	if d < time.Minute {
+		return catalog.T(i18n.ElapsedUnderMinute)
+	}
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	switch {
+	case hours == 0 && minutes == 1:
+		return catalog.T(i18n.ElapsedMinute)
+	case hours == 0:
+		return catalog.T(i18n.ElapsedMinutes, minutes)
+	case minutes == 0:
+		return catalog.T(i18n.ElapsedHours, hours)
+	}
+	return catalog.T(i18n.ElapsedHoursMinutes, hours, minutes)
+}
@@ -0,0 +1,369 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"lambda-to-discord/domain"
+	"lambda-to-discord/state"
+)
+
+func alarmEvent(state, changedAt string) json.RawMessage {
+	return json.RawMessage(`{
+  "AlarmName": "CPUHigh",
+  "NewStateValue": "` + state + `",
+  "NewStateReason": "Threshold Crossed",
+  "StateChangeTime": "` + changedAt + `",
+  "AlarmArn": "arn:aws:cloudwatch:us-east-1:123456789012:alarm:CPUHigh"
+}`)
+}
+
+func useStateStore(t *testing.T, store state.Store) {
+	t.Helper()
+	old := alarmStateStore
+	alarmStateStore = store
+	t.Cleanup(func() { alarmStateStore = old })
+}
+
+func TestHandleRequestEditsAlarmMessageOnResolve(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "cloudwatch")
+	t.Setenv(cloudWatchWebhookEnvVar, "https://discord.com/api/webhooks/200/cloudwatch")
+	store := state.NewMemoryStore()
+	useStateStore(t, store)
+	stub := &stubHTTPClient{responses: []*http.Response{
+		jsonResponse(http.StatusOK, `{"id":"111","channel_id":"222"}`),
+		jsonResponse(http.StatusOK, `{"id":"111","channel_id":"222"}`),
+	}}
+	useHTTPClient(t, stub)
+
+	if _, err := HandleRequest(context.Background(), alarmEvent("ALARM", "2024-01-02T03:04:05.000+0000")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := stub.requests[0].URL.String(); got != "https://discord.com/api/webhooks/200/cloudwatch?wait=true" {
+		t.Fatalf("expected ALARM to be sent with wait: %s", got)
+	}
+	record, ok, _ := store.Get(context.Background(), "arn:aws:cloudwatch:us-east-1:123456789012:alarm:CPUHigh")
+	if !ok || record.MessageID != "111" || record.WebhookID != "200" {
+		t.Fatalf("expected alarm message to be stored without the webhook token: %#v", record)
+	}
+
+	if _, err := HandleRequest(context.Background(), alarmEvent("OK", "2024-01-02T03:46:05.000+0000")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req := stub.requests[1]
+	if req.Method != http.MethodPatch || req.URL.String() != "https://discord.com/api/webhooks/200/cloudwatch/messages/111" {
+		t.Fatalf("expected original message to be edited: %s %s", req.Method, req.URL)
+	}
+	if !strings.Contains(stub.bodies[1], `"name":"Resolved After","value":"42 minutes"`) {
+		t.Fatalf("expected resolved field: %s", stub.bodies[1])
+	}
+	if !strings.Contains(stub.bodies[1], `"color":3066993`) {
+		t.Fatalf("expected edited embed to turn green: %s", stub.bodies[1])
+	}
+	record, _, _ = store.Get(context.Background(), "arn:aws:cloudwatch:us-east-1:123456789012:alarm:CPUHigh")
+	if want := time.Date(2024, 1, 2, 3, 46, 5, 0, time.UTC); !record.ResolvedAt.Equal(want) {
+		t.Fatalf("expected alarm state to be marked resolved: %#v", record)
+	}
+}
+
+func TestHandleRequestSkipsAlarmsAlreadyDeliveredOnRetry(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "cloudwatch")
+	t.Setenv(cloudWatchWebhookEnvVar, "https://discord.com/api/webhooks/200/cloudwatch")
+	useStateStore(t, state.NewMemoryStore())
+	stub := &stubHTTPClient{responses: []*http.Response{
+		jsonResponse(http.StatusOK, `{"id":"111","channel_id":"222"}`),
+		jsonResponse(http.StatusOK, `{"id":"111","channel_id":"222"}`),
+		jsonResponse(http.StatusOK, `{"id":"444","channel_id":"222"}`),
+	}}
+	useHTTPClient(t, stub)
+
+	alarm := alarmEvent("ALARM", "2024-01-02T03:04:05.000+0000")
+	ok := alarmEvent("OK", "2024-01-02T03:46:05.000+0000")
+	for _, event := range []json.RawMessage{alarm, alarm, ok, ok} {
+		if _, err := HandleRequest(context.Background(), event); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(stub.requests) != 2 {
+		t.Fatalf("expected retried events not to be sent again, got %d requests", len(stub.requests))
+	}
+
+	resp, err := HandleRequest(context.Background(), alarmEvent("ALARM", "2024-01-02T05:00:00.000+0000"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stub.requests) != 3 || stub.requests[2].Method != http.MethodPost || len(resp.Messages) != 1 || resp.Messages[0].ID != "444" {
+		t.Fatalf("expected a new ALARM after resolve to be sent: %d %#v", len(stub.requests), resp.Messages)
+	}
+}
+
+func TestHandleRequestSkipsResolveAlreadyPostedOnRetry(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "cloudwatch")
+	t.Setenv(cloudWatchWebhookEnvVar, "https://discord.com/api/webhooks/200/cloudwatch")
+	useStateStore(t, state.NewMemoryStore())
+	stub := &stubHTTPClient{}
+	useHTTPClient(t, stub)
+
+	ok := alarmEvent("OK", "2024-01-02T03:46:05.000+0000")
+	for i := 0; i < 2; i++ {
+		if _, err := HandleRequest(context.Background(), ok); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(stub.requests) != 1 {
+		t.Fatalf("expected an untracked resolve to be posted once, got %d requests", len(stub.requests))
+	}
+}
+
+func TestHandleRequestRepliesInAlarmThreadOnResolve(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "cloudwatch")
+	t.Setenv(cloudWatchWebhookEnvVar, "https://discord.com/api/webhooks/200/cloudwatch")
+	t.Setenv(alarmThreadsEnvVar, "true")
+	t.Setenv(alarmResolveModeEnvVar, "reply")
+	useStateStore(t, state.NewMemoryStore())
+	stub := &stubHTTPClient{responses: []*http.Response{
+		jsonResponse(http.StatusOK, `{"id":"111","channel_id":"333"}`),
+	}}
+	useHTTPClient(t, stub)
+
+	if _, err := HandleRequest(context.Background(), alarmEvent("ALARM", "2024-01-02T03:04:05.000+0000")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := HandleRequest(context.Background(), alarmEvent("OK", "2024-01-02T05:04:05.000+0000")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req := stub.requests[1]
+	if req.Method != http.MethodPost || req.URL.String() != "https://discord.com/api/webhooks/200/cloudwatch?thread_id=333" {
+		t.Fatalf("expected reply in the alarm thread: %s %s", req.Method, req.URL)
+	}
+	if strings.Contains(stub.bodies[1], "thread_name") {
+		t.Fatalf("expected reply not to create a new thread: %s", stub.bodies[1])
+	}
+	if !strings.Contains(stub.bodies[1], `"value":"2h"`) {
+		t.Fatalf("expected resolved field: %s", stub.bodies[1])
+	}
+}
+
+func TestHandleRequestReusesAlarmForumPost(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "cloudwatch")
+	t.Setenv(cloudWatchWebhookEnvVar, "https://discord.com/api/webhooks/200/cloudwatch")
+	t.Setenv(alarmThreadsEnvVar, "true")
+	store := state.NewMemoryStore()
+	useStateStore(t, store)
+	stub := &stubHTTPClient{responses: []*http.Response{
+		jsonResponse(http.StatusOK, `{"id":"111","channel_id":"333"}`),
+		jsonResponse(http.StatusOK, `{"id":"112","channel_id":"333"}`),
+		jsonResponse(http.StatusNotFound, `{"code":10003,"message":"Unknown Channel"}`),
+		jsonResponse(http.StatusOK, `{"id":"113","channel_id":"777"}`),
+	}}
+	useHTTPClient(t, stub)
+
+	for _, event := range []json.RawMessage{
+		alarmEvent("ALARM", "2024-01-02T03:04:05.000+0000"),
+		alarmEvent("INSUFFICIENT_DATA", "2024-01-02T03:14:05.000+0000"),
+		alarmEvent("INSUFFICIENT_DATA", "2024-01-02T03:24:05.000+0000"),
+	} {
+		if _, err := HandleRequest(context.Background(), event); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(stub.requests) != 4 {
+		t.Fatalf("unexpected requests: %d", len(stub.requests))
+	}
+	if !strings.Contains(stub.bodies[0], `"thread_name":"CPUHigh"`) {
+		t.Fatalf("expected the first state change to create a post: %s", stub.bodies[0])
+	}
+	if got := stub.requests[1].URL.Query().Get("thread_id"); got != "333" || strings.Contains(stub.bodies[1], "thread_name") {
+		t.Fatalf("expected later state changes to reuse the post: %s %s", stub.requests[1].URL, stub.bodies[1])
+	}
+	if got := stub.requests[3].URL.Query().Get("thread_id"); got != "" || !strings.Contains(stub.bodies[3], `"thread_name":"CPUHigh"`) {
+		t.Fatalf("expected a deleted post to be recreated: %s %s", stub.requests[3].URL, stub.bodies[3])
+	}
+	thread, ok, _ := store.Get(context.Background(), "thread|200|arn:aws:cloudwatch:us-east-1:123456789012:alarm:CPUHigh")
+	if !ok || thread.ThreadID != "777" {
+		t.Fatalf("expected the recreated post to be remembered: %#v", thread)
+	}
+}
+
+func TestHandleRequestSendsResolveWhenOriginalMessageIsGone(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "cloudwatch")
+	t.Setenv(cloudWatchWebhookEnvVar, "https://discord.com/api/webhooks/200/cloudwatch")
+	store := state.NewMemoryStore()
+	_ = store.Put(context.Background(), "arn:aws:cloudwatch:us-east-1:123456789012:alarm:CPUHigh", state.Record{
+		MessageID: "111",
+		WebhookID: "200",
+		StartedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	})
+	useStateStore(t, store)
+	stub := &stubHTTPClient{responses: []*http.Response{
+		jsonResponse(http.StatusNotFound, `{"code":10008,"message":"Unknown Message"}`),
+	}}
+	useHTTPClient(t, stub)
+
+	if _, err := HandleRequest(context.Background(), alarmEvent("OK", "2024-01-02T03:05:05.000+0000")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stub.requests) != 2 || stub.requests[1].Method != http.MethodPost {
+		t.Fatalf("expected a new message after the edit failed: %d requests", len(stub.requests))
+	}
+	if !strings.Contains(stub.bodies[1], `"value":"1 minute"`) {
+		t.Fatalf("expected resolved field: %s", stub.bodies[1])
+	}
+}
+
+func TestHandleRequestPostsResolveRoutedToAnotherWebhook(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "cloudwatch")
+	t.Setenv(cloudWatchWebhookEnvVar, "https://discord.com/api/webhooks/200/cloudwatch")
+	t.Setenv(routingConfigEnvVar, `{"routes":[{"match":{"state":"OK"},"targets":[{"webhook_url":"https://discord.com/api/webhooks/300/resolved"}]}]}`)
+	store := state.NewMemoryStore()
+	_ = store.Put(context.Background(), "arn:aws:cloudwatch:us-east-1:123456789012:alarm:CPUHigh", state.Record{
+		MessageID: "111",
+		ThreadID:  "333",
+		WebhookID: "200",
+		StartedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	})
+	useStateStore(t, store)
+	stub := &stubHTTPClient{}
+	useHTTPClient(t, stub)
+
+	resp, err := HandleRequest(context.Background(), alarmEvent("OK", "2024-01-02T03:05:05.000+0000"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stub.requests) != 1 || stub.requests[0].Method != http.MethodPost || stub.requests[0].URL.String() != "https://discord.com/api/webhooks/300/resolved" {
+		t.Fatalf("expected a new message on the routed webhook: %d requests", len(stub.requests))
+	}
+	if len(resp.Deliveries) != 1 || resp.Deliveries[0].Destination != "https://discord.com/api/webhooks/300/[REDACTED]" {
+		t.Fatalf("unexpected deliveries: %#v", resp.Deliveries)
+	}
+}
+
+func TestHandleRequestSendsResolveInAlarmThreadWhenOriginalMessageIsGone(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "cloudwatch")
+	t.Setenv(cloudWatchWebhookEnvVar, "https://discord.com/api/webhooks/200/cloudwatch")
+	t.Setenv(alarmThreadsEnvVar, "true")
+	store := state.NewMemoryStore()
+	_ = store.Put(context.Background(), "arn:aws:cloudwatch:us-east-1:123456789012:alarm:CPUHigh", state.Record{
+		MessageID: "111",
+		ThreadID:  "333",
+		WebhookID: "200",
+		StartedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	})
+	useStateStore(t, store)
+	stub := &stubHTTPClient{responses: []*http.Response{
+		jsonResponse(http.StatusNotFound, `{"code":10008,"message":"Unknown Message"}`),
+		jsonResponse(http.StatusOK, `{"id":"444","channel_id":"333"}`),
+	}}
+	useHTTPClient(t, stub)
+
+	if _, err := HandleRequest(context.Background(), alarmEvent("OK", "2024-01-02T03:05:05.000+0000")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stub.requests) != 2 || stub.requests[1].Method != http.MethodPost {
+		t.Fatalf("expected a new message after the edit failed: %d requests", len(stub.requests))
+	}
+	if got := stub.requests[1].URL.Query().Get("thread_id"); got != "333" {
+		t.Fatalf("expected the fallback to stay in the alarm thread: %s", stub.requests[1].URL)
+	}
+	if strings.Contains(stub.bodies[1], "thread_name") {
+		t.Fatalf("expected the fallback not to create a new post: %s", stub.bodies[1])
+	}
+}
+
+func TestHandleRequestRecreatesAlarmThreadWhenItIsGone(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "cloudwatch")
+	t.Setenv(cloudWatchWebhookEnvVar, "https://discord.com/api/webhooks/200/cloudwatch")
+	t.Setenv(alarmThreadsEnvVar, "true")
+	store := state.NewMemoryStore()
+	_ = store.Put(context.Background(), "arn:aws:cloudwatch:us-east-1:123456789012:alarm:CPUHigh", state.Record{
+		MessageID: "111",
+		ThreadID:  "333",
+		WebhookID: "200",
+	})
+	useStateStore(t, store)
+	stub := &stubHTTPClient{responses: []*http.Response{
+		jsonResponse(http.StatusNotFound, `{"code":10003,"message":"Unknown Channel"}`),
+		jsonResponse(http.StatusOK, `{"id":"444","channel_id":"555"}`),
+	}}
+	useHTTPClient(t, stub)
+
+	if _, err := HandleRequest(context.Background(), alarmEvent("OK", "2024-01-02T03:05:05.000+0000")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stub.requests) != 2 || stub.requests[1].URL.Query().Get("thread_id") != "" {
+		t.Fatalf("expected the fallback not to target the deleted thread: %d requests", len(stub.requests))
+	}
+	if !strings.Contains(stub.bodies[1], `"thread_name":"CPUHigh"`) {
+		t.Fatalf("expected the fallback to create a new post: %s", stub.bodies[1])
+	}
+}
+
+func TestLoadStateStore(t *testing.T) {
+	useStateStore(t, nil)
+
+	t.Setenv(stateStoreEnvVar, "")
+	if store, err := loadStateStore(context.Background()); err != nil || store != nil {
+		t.Fatalf("expected tracking to be disabled by default: %v %v", store, err)
+	}
+
+	t.Setenv(stateStoreEnvVar, "dynamodb")
+	if _, err := loadStateStore(context.Background()); err == nil || !strings.Contains(err.Error(), stateTableEnvVar) {
+		t.Fatalf("expected dynamodb without a table name to fail: %v", err)
+	}
+	t.Setenv(stateTableEnvVar, "alarms")
+	t.Setenv("AWS_REGION", "us-east-1")
+	store, err := loadStateStore(context.Background())
+	if _, ok := store.(*state.DynamoDBStore); err != nil || !ok {
+		t.Fatalf("expected a DynamoDB store: %T %v", store, err)
+	}
+	useStateStore(t, nil)
+
+	t.Setenv(stateStoreEnvVar, "redis")
+	if _, err := loadStateStore(context.Background()); err == nil {
+		t.Fatal("expected unsupported store to fail")
+	}
+
+	t.Setenv(stateStoreEnvVar, "file")
+	t.Setenv(stateFilePathEnvVar, filepath.Join(t.TempDir(), "state.json"))
+	store, err = loadStateStore(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := store.(*state.FileStore); !ok {
+		t.Fatalf("expected a file store: %T", store)
+	}
+	if again, _ := loadStateStore(context.Background()); again != store {
+		t.Fatal("expected the store to be reused across invocations")
+	}
+}
+
+func TestWithResolvedFieldKeepsOriginalPayload(t *testing.T) {
+	payload := domain.NotificationPayload{Embeds: []domain.Embed{{Title: "CPUHigh"}}}
+	start := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+
+	resolved := withResolvedField(payload, start, start.Add(90*time.Minute))
+	if len(payload.Embeds[0].Fields) != 0 {
+		t.Fatalf("expected original payload to be untouched: %#v", payload.Embeds[0].Fields)
+	}
+	if got := resolved.Embeds[0].Fields[0].Value; got != "1h 30m" {
+		t.Fatalf("unexpected elapsed: %s", got)
+	}
+}
+
+func TestWithResolvedFieldUsesPayloadLocale(t *testing.T) {
+	payload := domain.NotificationPayload{
+		Embeds:     []domain.Embed{{Title: "CPUHigh"}},
+		Attributes: map[string]string{domain.AttributeLocale: "ja"},
+	}
+	start := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+
+	field := withResolvedField(payload, start, start.Add(90*time.Minute)).Embeds[0].Fields[0]
+	if field.Name != "復旧までの時間" || field.Value != "1 時間 30 分" {
+		t.Fatalf("unexpected resolved field: %#v", field)
+	}
+}
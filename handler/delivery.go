@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"lambda-to-discord/discord"
+	"lambda-to-discord/domain"
+	"lambda-to-discord/redact"
+)
+
+const deliveryFailureModeEnvVar = "DELIVERY_FAILURE_MODE"
+
+type failureMode string
+
+const (
+	failOnAny failureMode = "any"
+	failOnAll failureMode = "all"
+)
+
+type Delivery struct {
+	Destination string            `json:"destination"`
+	StatusCode  int               `json:"statusCode,omitempty"`
+	Messages    []discord.Message `json:"messages,omitempty"`
+	Error       string            `json:"error,omitempty"`
+}
+
+type deliveryOutcome struct {
+	delivery Delivery
+	result   discord.Result
+	err      error
+}
+
+func loadFailureMode() (failureMode, error) {
+	switch mode := failureMode(strings.ToLower(strings.TrimSpace(os.Getenv(deliveryFailureModeEnvVar)))); mode {
+	case "", failOnAny:
+		return failOnAny, nil
+	case failOnAll:
+		return failOnAll, nil
+	default:
+		return "", fmt.Errorf("%s must be 'any' or 'all': %q", deliveryFailureModeEnvVar, mode)
+	}
+}
+
+func deliverToDestinations(
+	ctx context.Context,
+	tracker alarmTracker,
+	policy domain.WebhookPolicy,
+	payload domain.NotificationPayload,
+	opts ...discord.Option,
+) []deliveryOutcome {
+	destinations := payload.Destinations()
+	if len(destinations) == 0 {
+		destinations = []string{""}
+	}
+
+	outcomes := make([]deliveryOutcome, len(destinations))
+	var wg sync.WaitGroup
+	for i, destination := range destinations {
+		wg.Add(1)
+		go func(i int, destination string) {
+			defer wg.Done()
+			outcomes[i] = deliverToDestination(ctx, tracker, policy, payload.ForDestination(destination), opts...)
+			if err := outcomes[i].err; err != nil && len(destinations) > 1 {
+				outcomes[i].err = fmt.Errorf("%s: %w", outcomes[i].delivery.Destination, err)
+			}
+		}(i, destination)
+	}
+	wg.Wait()
+	return outcomes
+}
+
+func deliverToDestination(
+	ctx context.Context,
+	tracker alarmTracker,
+	policy domain.WebhookPolicy,
+	payload domain.NotificationPayload,
+	opts ...discord.Option,
+) deliveryOutcome {
+	outcome := deliveryOutcome{delivery: Delivery{Destination: redact.String(payload.WebhookURL)}}
+	if err := policy.Check(payload.WebhookURL); err != nil {
+		outcome.err = err
+	} else {
+		outcome.result, outcome.err = tracker.deliver(ctx, defaultHTTPClient, payload, opts...)
+	}
+
+	if outcome.err != nil {
+		outcome.delivery.Error = redact.String(outcome.err.Error())
+		return outcome
+	}
+	outcome.delivery.StatusCode = outcome.result.StatusCode
+	outcome.delivery.Messages = outcome.result.Messages
+	return outcome
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const fanOutEvent = `{"webhookURLs": ["https://discord.com/api/webhooks/1/team", "https://discord.com/api/webhooks/2/incidents"], "content": "deployed"}`
+
+func TestHandleRequestFansOutToEveryDestination(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "direct")
+	client := &stubHTTPClient{}
+	useHTTPClient(t, client)
+
+	resp, err := HandleRequest(context.Background(), json.RawMessage(fanOutEvent))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !client.sent("/api/webhooks/1/team") || !client.sent("/api/webhooks/2/incidents") {
+		t.Fatalf("expected both destinations to be called: %v", client.urls())
+	}
+	if len(resp.Deliveries) != 2 {
+		t.Fatalf("expected a delivery per destination: %#v", resp.Deliveries)
+	}
+	for _, delivery := range resp.Deliveries {
+		if delivery.StatusCode != http.StatusNoContent || delivery.Error != "" {
+			t.Fatalf("unexpected delivery: %#v", delivery)
+		}
+	}
+	if resp.Deliveries[0].Destination != "https://discord.com/api/webhooks/1/[REDACTED]" {
+		t.Fatalf("expected destinations in order without tokens: %s", resp.Deliveries[0].Destination)
+	}
+}
+
+func TestHandleRequestPartialFailureModes(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "direct")
+	t.Setenv(errorWebhookEnvVar, "https://discord.com/api/webhooks/600/error")
+	client := &stubHTTPClient{statuses: map[string]int{"/api/webhooks/2/incidents": http.StatusNotFound}}
+	useHTTPClient(t, client)
+
+	_, err := HandleRequest(context.Background(), json.RawMessage(fanOutEvent))
+	if err == nil || !strings.Contains(err.Error(), "https://discord.com/api/webhooks/2/[REDACTED]") {
+		t.Fatalf("expected failure naming the destination by default: %v", err)
+	}
+
+	t.Setenv(deliveryFailureModeEnvVar, "all")
+	client = &stubHTTPClient{statuses: map[string]int{"/api/webhooks/2/incidents": http.StatusNotFound}}
+	useHTTPClient(t, client)
+	resp, err := HandleRequest(context.Background(), json.RawMessage(fanOutEvent))
+	if err != nil {
+		t.Fatalf("expected partial failure to be tolerated: %v", err)
+	}
+	if resp.Deliveries[0].Error != "" || !strings.Contains(resp.Deliveries[1].Error, "Unknown Webhook") {
+		t.Fatalf("expected per-destination errors: %#v", resp.Deliveries)
+	}
+	if !client.sent("/api/webhooks/600/error") {
+		t.Fatal("expected the partial failure to be reported to the error webhook")
+	}
+
+	client = &stubHTTPClient{statuses: map[string]int{
+		"/api/webhooks/1/team":      http.StatusNotFound,
+		"/api/webhooks/2/incidents": http.StatusNotFound,
+	}}
+	useHTTPClient(t, client)
+	if _, err := HandleRequest(context.Background(), json.RawMessage(fanOutEvent)); err == nil {
+		t.Fatal("expected failure when every destination fails")
+	}
+}
+
+func TestHandleRequestFailsWhenOneNotificationFailsEverywhere(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "cloudwatch")
+	t.Setenv(cloudWatchWebhookEnvVar, "https://discord.com/api/webhooks/200/cloudwatch")
+	t.Setenv(routingConfigEnvVar, `{"routes":[{"match":{"alarm_name":"cpu-*"},"targets":[{"webhook_url":"https://discord.com/api/webhooks/1/infra"}]}]}`)
+	t.Setenv(deliveryFailureModeEnvVar, "all")
+	client := &stubHTTPClient{statuses: map[string]int{"/api/webhooks/1/infra": http.StatusNotFound}}
+	useHTTPClient(t, client)
+
+	cpu, _ := json.Marshal(strings.Replace(sampleAlarmMessage, `"CPUHigh"`, `"cpu-high"`, 1))
+	disk, _ := json.Marshal(strings.Replace(sampleAlarmMessage, `"CPUHigh"`, `"disk-full"`, 1))
+	raw := json.RawMessage(`{"Records":[{"Sns":{"Message":` + string(cpu) + `}},{"Sns":{"Message":` + string(disk) + `}}]}`)
+	_, err := HandleRequest(context.Background(), raw)
+	if err == nil || !strings.Contains(err.Error(), "Unknown Webhook") {
+		t.Fatalf("expected the notification that failed on every destination to fail the invocation: %v", err)
+	}
+	if !client.sent("/api/webhooks/200/cloudwatch") {
+		t.Fatalf("expected the other notification to be delivered: %v", client.urls())
+	}
+}
+
+func TestLoadFailureMode(t *testing.T) {
+	for value, want := range map[string]failureMode{"": failOnAny, "ANY": failOnAny, " all ": failOnAll} {
+		t.Setenv(deliveryFailureModeEnvVar, value)
+		if got, err := loadFailureMode(); err != nil || got != want {
+			t.Fatalf("loadFailureMode(%q) = %q, %v", value, got, err)
+		}
+	}
+
+	t.Setenv(deliveryFailureModeEnvVar, "most")
+	if _, err := loadFailureMode(); err == nil {
+		t.Fatal("expected unsupported mode to fail")
+	}
+}
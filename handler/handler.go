@@ -0,0 +1,504 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"lambda-to-discord/adapter"
+	"lambda-to-discord/discord"
+	"lambda-to-discord/domain"
+	"lambda-to-discord/i18n"
+	"lambda-to-discord/redact"
+	"lambda-to-discord/routing"
+	"lambda-to-discord/templates"
+)
+
+var defaultHTTPClient discord.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+const (
+	errorWebhookEnvVar      = "ERROR_WEBHOOK_URL"
+	adapterTypeEnvVar       = "ADAPTER_TYPE"
+	cloudWatchWebhookEnvVar = "WEBHOOK_URL"
+	awsRegionEnvVar         = "AWS_REGION"
+	snsCombineRecordsEnvVar = "SNS_COMBINE_RECORDS"
+	alarmThreadsEnvVar      = "CLOUDWATCH_ALARM_THREADS"
+	alarmThreadIDsEnvVar    = "CLOUDWATCH_ALARM_THREAD_IDS"
+	alarmSilentStatesEnvVar = "CLOUDWATCH_SILENT_STATES"
+	discordWaitEnvVar       = "DISCORD_WAIT"
+	discordComponentsEnvVar = "DISCORD_COMPONENTS"
+	retryMaxAttemptsEnvVar  = "RETRY_MAX_ATTEMPTS"
+	retryBaseDelayEnvVar    = "RETRY_BASE_DELAY"
+	retryMaxDelayEnvVar     = "RETRY_MAX_DELAY"
+	retryJitterEnvVar       = "RETRY_JITTER"
+	retryStatusesEnvVar     = "RETRY_STATUSES"
+	allowedHostsEnvVar      = "WEBHOOK_ALLOWED_HOSTS"
+	allowedIDsEnvVar        = "WEBHOOK_ALLOWED_IDS"
+	redactKeysEnvVar        = "REDACT_KEYS"
+	routingConfigEnvVar     = "ROUTING_CONFIG"
+	routingConfigFileEnvVar = "ROUTING_CONFIG_FILE"
+	templatesEnvVar         = "MESSAGE_TEMPLATES"
+	templatesFileEnvVar     = "MESSAGE_TEMPLATES_FILE"
+	messageLanguageEnvVar   = "MESSAGE_LANGUAGE"
+	displayTimezoneEnvVar   = "DISPLAY_TIMEZONE"
+)
+
+type Response struct {
+	StatusCode        int                `json:"statusCode"`
+	Body              string             `json:"body"`
+	Messages          []discord.Message  `json:"messages,omitempty"`
+	Deliveries        []Delivery         `json:"deliveries,omitempty"`
+	Errors            []string           `json:"errors,omitempty"`
+	BatchItemFailures []BatchItemFailure `json:"batchItemFailures,omitempty"`
+}
+
+type BatchItemFailure struct {
+	ItemIdentifier string `json:"itemIdentifier"`
+}
+
+type settings struct {
+	adapter       adapter.Config
+	catalog       i18n.Catalog
+	router        *routing.Router
+	tracker       alarmTracker
+	webhookPolicy domain.WebhookPolicy
+	failureMode   failureMode
+	wait          bool
+	opts          []discord.Option
+}
+
+func loadSettings(ctx context.Context) (settings, error) {
+	adapterCfg, err := loadAdapterConfig()
+	if err != nil {
+		return settings{}, err
+	}
+	catalog, err := loadCatalog()
+	if err != nil {
+		return settings{}, err
+	}
+	router, err := loadRouter()
+	if err != nil {
+		return settings{}, err
+	}
+	retryPolicy, err := loadRetryPolicy()
+	if err != nil {
+		return settings{}, err
+	}
+	store, err := loadStateStore(ctx)
+	if err != nil {
+		return settings{}, err
+	}
+	resolveMode, err := loadResolveMode()
+	if err != nil {
+		return settings{}, err
+	}
+	failureMode, err := loadFailureMode()
+	if err != nil {
+		return settings{}, err
+	}
+
+	return settings{
+		adapter:       adapterCfg,
+		catalog:       catalog,
+		router:        router,
+		tracker:       alarmTracker{store: store, resolveMode: resolveMode},
+		webhookPolicy: loadWebhookPolicy(),
+		failureMode:   failureMode,
+		wait:          parseBoolEnv(discordWaitEnvVar),
+		opts: []discord.Option{
+			discord.WithRetryPolicy(retryPolicy),
+			discord.WithComponentFallback(!parseBoolEnv(discordComponentsEnvVar)),
+		},
+	}, nil
+}
+
+func HandleRequest(ctx context.Context, event json.RawMessage) (Response, error) {
+	adapterType := strings.ToLower(strings.TrimSpace(os.Getenv(adapterTypeEnvVar)))
+
+	conf, err := loadSettings(ctx)
+	if err != nil {
+		notifyProcessingError(ctx, defaultHTTPClient, event, nil, err)
+		return Response{}, redact.Error(err)
+	}
+	if messages, ok := adapter.ParseSQSEvent(event); ok {
+		return handleSQSEvent(ctx, adapterType, conf, messages), nil
+	}
+	resp, err := handleEvent(ctx, adapterType, conf, event)
+	return resp, redact.Error(err)
+}
+
+func handleSQSEvent(ctx context.Context, adapterType string, conf settings, messages []adapter.SQSMessage) Response {
+	resp := Response{StatusCode: http.StatusOK}
+	for _, message := range messages {
+		if _, err := handleEvent(ctx, adapterType, conf, json.RawMessage(message.Body)); err != nil {
+			resp.BatchItemFailures = append(resp.BatchItemFailures, BatchItemFailure{ItemIdentifier: message.MessageID})
+		}
+	}
+	return resp
+}
+
+func handleEvent(ctx context.Context, adapterType string, conf settings, event json.RawMessage) (Response, error) {
+	payloads, eventMap, buildErr := buildNotificationPayloads(adapterType, conf, event)
+	if buildErr != nil && len(payloads) == 0 {
+		notifyProcessingError(ctx, defaultHTTPClient, event, eventMap, buildErr)
+		return Response{}, buildErr
+	}
+
+	resp := Response{StatusCode: http.StatusNoContent}
+	if buildErr != nil {
+		resp.Errors = append(resp.Errors, redact.String(buildErr.Error()))
+	}
+	var errs []error
+	tolerated := []error{buildErr}
+	for _, payload := range payloads {
+		payload = conf.router.Route(payload)
+		payload.Wait = payload.Wait || conf.wait
+		var (
+			deliveryErrs []error
+			delivered    int
+		)
+		for _, outcome := range deliverToDestinations(ctx, conf.tracker, conf.webhookPolicy, payload, conf.opts...) {
+			resp.Deliveries = append(resp.Deliveries, outcome.delivery)
+			if outcome.err != nil {
+				deliveryErrs = append(deliveryErrs, outcome.err)
+				continue
+			}
+			delivered++
+			resp.StatusCode = outcome.result.StatusCode
+			resp.Body = outcome.result.Body
+			resp.Messages = append(resp.Messages, outcome.result.Messages...)
+		}
+
+		if conf.failureMode == failOnAll && delivered > 0 {
+			tolerated = append(tolerated, deliveryErrs...)
+		} else {
+			errs = append(errs, deliveryErrs...)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		notifyProcessingError(ctx, defaultHTTPClient, event, eventMap, errors.Join(append([]error{err}, tolerated...)...))
+		return Response{}, err
+	}
+	notifyProcessingError(ctx, defaultHTTPClient, event, eventMap, errors.Join(tolerated...))
+	return resp, nil
+}
+
+func dispatch(ctx context.Context, client discord.HTTPClient, payload domain.NotificationPayload, opts ...discord.Option) (discord.Result, error) {
+	switch payload.Action {
+	case domain.ActionEdit:
+		return discord.Edit(ctx, client, payload.WebhookURL, payload.MessageID, payload, opts...)
+	case domain.ActionDelete:
+		if err := discord.Delete(ctx, client, payload.WebhookURL, payload.MessageID, payload.ThreadID, opts...); err != nil {
+			return discord.Result{}, err
+		}
+		return discord.Result{StatusCode: http.StatusNoContent}, nil
+	default:
+		return discord.Send(ctx, client, payload, opts...)
+	}
+}
+
+func buildNotificationPayloads(adapterType string, conf settings, event json.RawMessage) ([]domain.NotificationPayload, map[string]any, error) {
+	switch adapterType {
+	case "":
+		adapterType = "direct"
+	case "auto":
+		detected, err := adapter.Detect(event)
+		if err != nil {
+			return nil, nil, err
+		}
+		adapterType = detected
+	}
+
+	cfg := conf.adapter
+	cfg.Locale = localeResolver(adapterType, conf.catalog, conf.router)
+
+	a, err := adapter.New(adapterType, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	payloads, eventMap, err := a.Transform(event)
+	for i := range payloads {
+		payloads[i] = payloads[i].WithAttribute(domain.AttributeAdapter, strings.ToLower(adapterType))
+		if payloads[i].Event == nil {
+			payloads[i].Event = eventMap
+		}
+	}
+	return payloads, eventMap, err
+}
+
+func loadCatalog() (i18n.Catalog, error) {
+	catalog, err := i18n.Lookup(os.Getenv(messageLanguageEnvVar))
+	if err != nil {
+		return i18n.Catalog{}, fmt.Errorf("%s: %w", messageLanguageEnvVar, err)
+	}
+	return catalog, nil
+}
+
+func localeResolver(adapterType string, fallback i18n.Catalog, router *routing.Router) adapter.LocaleResolver {
+	return func(attributes map[string]string, event map[string]any) i18n.Catalog {
+		matched := map[string]string{domain.AttributeAdapter: strings.ToLower(adapterType)}
+		for name, value := range attributes {
+			matched[name] = value
+		}
+		language := router.Locale(matched, event)
+		if language == "" {
+			return fallback
+		}
+		catalog, err := i18n.Lookup(language)
+		if err != nil {
+			return fallback
+		}
+		return catalog
+	}
+}
+
+func loadAdapterConfig() (adapter.Config, error) {
+	cfg := adapter.Config{
+		WebhookURL:        os.Getenv(cloudWatchWebhookEnvVar),
+		Region:            os.Getenv(awsRegionEnvVar),
+		CombineSNSRecords: parseBoolEnv(snsCombineRecordsEnvVar),
+		AlarmThreads:      parseBoolEnv(alarmThreadsEnvVar),
+	}
+
+	cfg.AlarmSilentStates = parseListEnv(alarmSilentStatesEnvVar)
+
+	set, err := loadTemplates()
+	if err != nil {
+		return adapter.Config{}, err
+	}
+	cfg.Templates = set
+
+	if value := strings.TrimSpace(os.Getenv(displayTimezoneEnvVar)); value != "" {
+		location, err := time.LoadLocation(value)
+		if err != nil {
+			return adapter.Config{}, fmt.Errorf("%s must be an IANA time zone name such as Asia/Tokyo: %w", displayTimezoneEnvVar, err)
+		}
+		cfg.DisplayLocation = location
+	}
+
+	if value := strings.TrimSpace(os.Getenv(alarmThreadIDsEnvVar)); value != "" {
+		if err := json.Unmarshal([]byte(value), &cfg.AlarmThreadIDs); err != nil {
+			return adapter.Config{}, fmt.Errorf("%s must be a JSON object of alarm name to thread id: %w", alarmThreadIDsEnvVar, err)
+		}
+	}
+
+	return cfg, nil
+}
+
+func parseBoolEnv(key string) bool {
+	enabled, err := strconv.ParseBool(strings.TrimSpace(os.Getenv(key)))
+	return err == nil && enabled
+}
+
+func parseListEnv(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func loadRouter() (*routing.Router, error) {
+	data, source, err := readConfigSource(routingConfigEnvVar, routingConfigFileEnvVar)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	router, err := routing.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+	return router, nil
+}
+
+func loadTemplates() (templates.Set, error) {
+	data, source, err := readConfigSource(templatesEnvVar, templatesFileEnvVar)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	set, err := templates.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+	return set, nil
+}
+
+func readConfigSource(inlineKey, fileKey string) ([]byte, string, error) {
+	if value := strings.TrimSpace(os.Getenv(inlineKey)); value != "" {
+		return []byte(value), inlineKey, nil
+	}
+	path := strings.TrimSpace(os.Getenv(fileKey))
+	if path == "" {
+		return nil, "", nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s: %w", fileKey, err)
+	}
+	return data, path, nil
+}
+
+func loadWebhookPolicy() domain.WebhookPolicy {
+	return domain.WebhookPolicy{
+		AllowedHosts: parseListEnv(allowedHostsEnvVar),
+		AllowedIDs:   parseListEnv(allowedIDsEnvVar),
+	}
+}
+
+func loadRetryPolicy() (discord.RetryPolicy, error) {
+	policy := discord.DefaultRetryPolicy()
+
+	if value := strings.TrimSpace(os.Getenv(retryMaxAttemptsEnvVar)); value != "" {
+		attempts, err := strconv.Atoi(value)
+		if err != nil || attempts < 1 {
+			return discord.RetryPolicy{}, fmt.Errorf("%s must be a positive integer: %q", retryMaxAttemptsEnvVar, value)
+		}
+		policy.MaxAttempts = attempts
+	}
+	if value := strings.TrimSpace(os.Getenv(retryBaseDelayEnvVar)); value != "" {
+		delay, err := time.ParseDuration(value)
+		if err != nil || delay < 0 {
+			return discord.RetryPolicy{}, fmt.Errorf("%s must be a non-negative duration: %q", retryBaseDelayEnvVar, value)
+		}
+		policy.BaseDelay = delay
+	}
+	if value := strings.TrimSpace(os.Getenv(retryMaxDelayEnvVar)); value != "" {
+		delay, err := time.ParseDuration(value)
+		if err != nil || delay < 0 {
+			return discord.RetryPolicy{}, fmt.Errorf("%s must be a non-negative duration: %q", retryMaxDelayEnvVar, value)
+		}
+		policy.MaxDelay = delay
+	}
+	if value := strings.TrimSpace(os.Getenv(retryJitterEnvVar)); value != "" {
+		jitter, err := strconv.ParseFloat(value, 64)
+		if err != nil || jitter < 0 || jitter > 1 {
+			return discord.RetryPolicy{}, fmt.Errorf("%s must be a number between 0 and 1: %q", retryJitterEnvVar, value)
+		}
+		policy.Jitter = jitter
+	}
+	if values := parseListEnv(retryStatusesEnvVar); len(values) > 0 {
+		policy.RetryableStatuses = nil
+		for _, value := range values {
+			status, err := strconv.Atoi(value)
+			if err != nil || status < 400 || status > 599 {
+				return discord.RetryPolicy{}, fmt.Errorf("%s must be a comma-separated list of 4xx or 5xx status codes: %q", retryStatusesEnvVar, value)
+			}
+			policy.RetryableStatuses = append(policy.RetryableStatuses, status)
+		}
+	}
+
+	return policy, nil
+}
+
+func notifyProcessingError(
+	ctx context.Context,
+	client discord.HTTPClient,
+	rawEvent json.RawMessage,
+	event map[string]any,
+	procErr error,
+) {
+	if procErr == nil {
+		return
+	}
+
+	webhookURL := strings.TrimSpace(os.Getenv(errorWebhookEnvVar))
+	if webhookURL == "" {
+		return
+	}
+
+	payload := buildErrorNotificationPayload(webhookURL, rawEvent, event, procErr)
+
+	if client == nil {
+		client = defaultHTTPClient
+	}
+
+	_, _ = discord.Send(ctx, client, payload)
+}
+
+func buildErrorNotificationPayload(webhookURL string, rawEvent json.RawMessage, event map[string]any, procErr error) domain.NotificationPayload {
+	redactor := loadRedactor()
+	catalog, err := loadCatalog()
+	if err != nil {
+		catalog = i18n.Default()
+	}
+	payload := domain.NotificationPayload{
+		WebhookURL:      webhookURL,
+		Content:         redact.String(catalog.T(i18n.ErrorSummary, procErr)),
+		AllowedMentions: domain.NoMentions(),
+	}
+
+	description := formatRequestForNotification(redactor, rawEvent, event)
+	if utf8.RuneCountInString(description) > domain.MaxEmbedDescriptionLength {
+		dump, isJSON := requestDump(redactor, rawEvent, event)
+		attachment := domain.Attachment{Name: "request.txt", ContentType: "text/plain; charset=utf-8", Data: dump}
+		if isJSON {
+			attachment.Name = "request.json"
+			attachment.ContentType = "application/json"
+		}
+		payload.Attachments = []domain.Attachment{attachment}
+		return payload
+	}
+	if description != "" {
+		payload.Embeds = []domain.Embed{
+			{
+				Title:       catalog.T(i18n.ErrorRequest),
+				Description: description,
+			},
+		}
+	}
+
+	return payload
+}
+
+func loadRedactor() redact.Redactor {
+	return redact.New(parseListEnv(redactKeysEnvVar)...)
+}
+
+func formatRequestForNotification(redactor redact.Redactor, rawEvent json.RawMessage, event map[string]any) string {
+	dump, isJSON := requestDump(redactor, rawEvent, event)
+	if len(dump) == 0 {
+		return ""
+	}
+	if isJSON {
+		return wrapAsJSONCodeBlock(dump)
+	}
+	return fmt.Sprintf("```\n%s\n```", string(dump))
+}
+
+func requestDump(redactor redact.Redactor, rawEvent json.RawMessage, event map[string]any) ([]byte, bool) {
+	if event != nil {
+		if b, err := json.MarshalIndent(redactor.Value(event), "", "  "); err == nil {
+			return b, true
+		}
+	}
+
+	trimmed := bytes.TrimSpace(rawEvent)
+	if len(trimmed) == 0 {
+		return nil, false
+	}
+
+	if json.Valid(trimmed) {
+		redacted := redactor.JSON(trimmed)
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, redacted, "", "  "); err == nil {
+			return buf.Bytes(), true
+		}
+		return redacted, true
+	}
+
+	return []byte(redact.String(string(trimmed))), false
+}
+
+func wrapAsJSONCodeBlock(data []byte) string {
+	return fmt.Sprintf("```json\n%s\n```", string(data))
+}
@@ -0,0 +1,812 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"lambda-to-discord/adapter"
+	"lambda-to-discord/discord"
+	"lambda-to-discord/domain"
+)
+
+type stubHTTPClient struct {
+	mu        sync.Mutex
+	req       *http.Request
+	resp      *http.Response
+	err       error
+	responses []*http.Response
+	statuses  map[string]int
+	requests  []*http.Request
+	bodies    []string
+}
+
+func (s *stubHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	s.req = req
+	s.requests = append(s.requests, req)
+	s.bodies = append(s.bodies, string(body))
+
+	if s.err != nil {
+		return nil, s.err
+	}
+	if len(s.responses) > 0 {
+		resp := s.responses[0]
+		s.responses = s.responses[1:]
+		return resp, nil
+	}
+	if status, ok := s.statuses[req.URL.Path]; ok {
+		if status >= 400 {
+			return jsonResponse(status, `{"code":10015,"message":"Unknown Webhook"}`), nil
+		}
+		return jsonResponse(status, ""), nil
+	}
+	if s.resp == nil {
+		return jsonResponse(http.StatusNoContent, ""), nil
+	}
+	return s.resp, nil
+}
+
+func (s *stubHTTPClient) urls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	urls := make([]string, 0, len(s.requests))
+	for _, req := range s.requests {
+		urls = append(urls, req.URL.String())
+	}
+	return urls
+}
+
+func (s *stubHTTPClient) sent(path string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, req := range s.requests {
+		if req.URL.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+func useHTTPClient(t *testing.T, client discord.HTTPClient) {
+	t.Helper()
+	old := defaultHTTPClient
+	defaultHTTPClient = client
+	t.Cleanup(func() { defaultHTTPClient = old })
+}
+
+func jsonResponse(status int, body string) *http.Response {
+	return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+func TestHandleRequestDirectSuccess(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "direct")
+	stub := &stubHTTPClient{resp: &http.Response{StatusCode: http.StatusAccepted, Body: io.NopCloser(strings.NewReader("ok"))}}
+	useHTTPClient(t, stub)
+
+	event := map[string]any{
+		"webhookURL": "https://discord.com/api/webhooks/500/direct",
+		"content":    "hello",
+	}
+	raw, _ := json.Marshal(event)
+
+	resp, err := HandleRequest(context.Background(), raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("unexpected status: %d", resp.StatusCode)
+	}
+	if stub.req == nil {
+		t.Fatalf("expected request to be dispatched")
+	}
+}
+
+func TestHandleRequestReturnsCreatedMessages(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "direct")
+	t.Setenv(discordWaitEnvVar, "true")
+	stub := &stubHTTPClient{resp: &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"id":"111","channel_id":"222","timestamp":"2024-01-02T03:04:05+00:00"}`))}}
+	useHTTPClient(t, stub)
+
+	raw := json.RawMessage(`{"webhookURL": "https://discord.com/api/webhooks/500/direct", "content": "hello"}`)
+	resp, err := HandleRequest(context.Background(), raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stub.req.URL.Query().Get("wait") != "true" {
+		t.Fatalf("expected wait=true: %s", stub.req.URL)
+	}
+	if len(resp.Messages) != 1 || resp.Messages[0].ID != "111" {
+		t.Fatalf("unexpected messages: %#v", resp.Messages)
+	}
+	encoded, _ := json.Marshal(resp)
+	if !strings.Contains(string(encoded), `"messages":[{"id":"111","channel_id":"222"`) {
+		t.Fatalf("unexpected response encoding: %s", string(encoded))
+	}
+}
+
+func TestHandleRequestEditsAndDeletesMessages(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "direct")
+	stub := &stubHTTPClient{resp: &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"id":"111","channel_id":"222"}`))}}
+	useHTTPClient(t, stub)
+
+	raw := json.RawMessage(`{"webhookURL": "https://discord.com/api/webhooks/500/direct", "content": "succeeded", "action": "edit", "message_id": "111"}`)
+	resp, err := HandleRequest(context.Background(), raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stub.req.Method != http.MethodPatch || stub.req.URL.Path != "/api/webhooks/500/direct/messages/111" {
+		t.Fatalf("unexpected edit request: %s %s", stub.req.Method, stub.req.URL)
+	}
+	if len(resp.Messages) != 1 || resp.Messages[0].ID != "111" {
+		t.Fatalf("unexpected messages: %#v", resp.Messages)
+	}
+
+	stub.resp = nil
+	raw = json.RawMessage(`{"webhookURL": "https://discord.com/api/webhooks/500/direct", "action": "delete", "message_id": "111"}`)
+	resp, err = HandleRequest(context.Background(), raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stub.req.Method != http.MethodDelete || resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("unexpected delete request: %s %d", stub.req.Method, resp.StatusCode)
+	}
+}
+
+func TestHandleRequestRejectsDisallowedWebhooks(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "direct")
+	t.Setenv(allowedIDsEnvVar, "500, 501")
+	stub := &stubHTTPClient{}
+	useHTTPClient(t, stub)
+
+	events := map[string]string{
+		`{"webhookURL": "https://attacker.example/api/webhooks/500/direct", "content": "hi"}`: "not a Discord host",
+		`{"webhookURL": "https://discord.com/api/webhooks/999/other", "content": "hi"}`:       "not in the allowed webhook ids",
+	}
+	for event, want := range events {
+		_, err := HandleRequest(context.Background(), json.RawMessage(event))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q error, got %v", want, err)
+		}
+	}
+	if len(stub.urls()) != 0 {
+		t.Fatalf("expected no requests to be sent: %v", stub.urls())
+	}
+
+	if _, err := HandleRequest(context.Background(), json.RawMessage(`{"webhookURL": "https://discord.com/api/webhooks/500/direct", "content": "hi"}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stub.urls()) != 1 {
+		t.Fatalf("expected allowed webhook to be called: %v", stub.urls())
+	}
+}
+
+func TestHandleRequestCloudWatchSuccess(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "cloudwatch")
+	t.Setenv(cloudWatchWebhookEnvVar, "https://discord.com/api/webhooks/200/cloudwatch")
+	stub := &stubHTTPClient{}
+	useHTTPClient(t, stub)
+
+	raw := json.RawMessage(sampleAlarmMessage)
+	resp, err := HandleRequest(context.Background(), raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d", resp.StatusCode)
+	}
+}
+
+func TestHandleRequestRendersAlarmButtons(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "cloudwatch")
+	t.Setenv(cloudWatchWebhookEnvVar, "https://discord.com/api/webhooks/200/cloudwatch")
+	stub := &stubHTTPClient{}
+	useHTTPClient(t, stub)
+	if _, err := HandleRequest(context.Background(), json.RawMessage(sampleAlarmMessage)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(stub.req.Body)
+	if strings.Contains(string(body), `"components"`) || !strings.Contains(string(body), "[Open alarm](https://us-east-1.console.aws.amazon.com/") {
+		t.Fatalf("expected buttons to fall back to markdown links: %s", body)
+	}
+
+	t.Setenv(discordComponentsEnvVar, "true")
+	stub = &stubHTTPClient{}
+	useHTTPClient(t, stub)
+	if _, err := HandleRequest(context.Background(), json.RawMessage(sampleAlarmMessage)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ = io.ReadAll(stub.req.Body)
+	if !strings.Contains(string(body), `"label":"Open alarm"`) || stub.req.URL.Query().Get("with_components") != "true" {
+		t.Fatalf("expected link buttons to be sent as components: %s %s", stub.req.URL, body)
+	}
+}
+
+func TestHandleRequestEventBridgeSuccess(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "eventbridge")
+	t.Setenv(cloudWatchWebhookEnvVar, "https://discord.com/api/webhooks/700/eventbridge")
+	stub := &stubHTTPClient{}
+	useHTTPClient(t, stub)
+
+	raw := json.RawMessage(`{"source":"aws.ec2","detail-type":"EC2 Instance State-change Notification","detail":{"state":"stopped"}}`)
+	if _, err := HandleRequest(context.Background(), raw); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stub.req == nil || stub.req.URL.String() != "https://discord.com/api/webhooks/700/eventbridge" {
+		t.Fatalf("expected request to be dispatched to the eventbridge webhook")
+	}
+}
+
+func TestHandleRequestSkipsCloudWatchLogsControlMessage(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "cloudwatch_logs")
+	t.Setenv(cloudWatchWebhookEnvVar, "https://discord.com/api/webhooks/900/logs")
+	stub := &stubHTTPClient{}
+	useHTTPClient(t, stub)
+
+	// {"messageType":"CONTROL_MESSAGE","logEvents":[]} gzipped and base64 encoded.
+	raw := json.RawMessage(`{"awslogs":{"data":"H4sIAHnY0WoC/6tWyk0tLk5MTw2pLEhVslJy9vcLCfL3ifd1DQ52dHdV0lHKyU93LUvNKylWsoqOrQUATWsQOjAAAAA="}}`)
+	resp, err := HandleRequest(context.Background(), raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d", resp.StatusCode)
+	}
+	if stub.req != nil {
+		t.Fatal("expected control message not to be sent to Discord")
+	}
+}
+
+func TestHandleRequestSQSBatchReportsFailures(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "direct")
+	t.Setenv(cloudWatchWebhookEnvVar, "https://discord.com/api/webhooks/500/direct")
+	stub := &stubHTTPClient{}
+	useHTTPClient(t, stub)
+
+	raw := json.RawMessage(`{"Records":[
+		{"messageId":"m-1","eventSource":"aws:sqs","body":"{\"content\":\"first\"}"},
+		{"messageId":"m-2","eventSource":"aws:sqs","body":"{\"username\":\"no content\"}"},
+		{"messageId":"m-3","eventSource":"aws:sqs","body":"{\"content\":\"third\"}"}
+	]}`)
+	resp, err := HandleRequest(context.Background(), raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.BatchItemFailures) != 1 || resp.BatchItemFailures[0].ItemIdentifier != "m-2" {
+		t.Fatalf("unexpected batch item failures: %#v", resp.BatchItemFailures)
+	}
+	body, readErr := io.ReadAll(stub.req.Body)
+	if readErr != nil {
+		t.Fatalf("failed to read body: %v", readErr)
+	}
+	if !strings.Contains(string(body), "third") {
+		t.Fatalf("expected the last message to be delivered after a failure: %s", string(body))
+	}
+
+	encoded, _ := json.Marshal(resp)
+	if !strings.Contains(string(encoded), `"batchItemFailures":[{"itemIdentifier":"m-2"}]`) {
+		t.Fatalf("unexpected response encoding: %s", string(encoded))
+	}
+}
+
+func TestHandleRequestLoadsConfigOncePerSQSBatch(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "direct")
+	t.Setenv(errorWebhookEnvVar, "https://discord.com/api/webhooks/600/error")
+	t.Setenv(routingConfigEnvVar, `{"routes": [}`)
+	stub := &stubHTTPClient{}
+	useHTTPClient(t, stub)
+
+	raw := json.RawMessage(`{"Records":[
+		{"messageId":"m-1","eventSource":"aws:sqs","body":"{\"content\":\"first\"}"},
+		{"messageId":"m-2","eventSource":"aws:sqs","body":"{\"content\":\"second\"}"}
+	]}`)
+	if _, err := HandleRequest(context.Background(), raw); err == nil || !strings.Contains(err.Error(), routingConfigEnvVar) {
+		t.Fatalf("expected the invalid routing config to fail the invocation: %v", err)
+	}
+	if len(stub.urls()) != 1 || stub.urls()[0] != "https://discord.com/api/webhooks/600/error" {
+		t.Fatalf("expected a single error notification for the batch: %v", stub.urls())
+	}
+}
+
+func TestHandleRequestSendsEverySNSRecord(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "cloudwatch")
+	t.Setenv(cloudWatchWebhookEnvVar, "https://discord.com/api/webhooks/200/cloudwatch")
+	t.Setenv(errorWebhookEnvVar, "https://discord.com/api/webhooks/600/error")
+	stub := &stubHTTPClient{}
+	useHTTPClient(t, stub)
+
+	message, _ := json.Marshal(sampleAlarmMessage)
+	record := `{"Sns":{"Message":` + string(message) + `}}`
+	raw := json.RawMessage(`{"Records":[` + record + `,{"Sns":{"Message":"broken"}},` + record + `]}`)
+
+	resp, err := HandleRequest(context.Background(), raw)
+	if err != nil {
+		t.Fatalf("expected an undecodable record not to fail the other records: %v", err)
+	}
+	if len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0], "record 1") {
+		t.Fatalf("expected per-record error in the response, got %#v", resp.Errors)
+	}
+	want := []string{"https://discord.com/api/webhooks/200/cloudwatch", "https://discord.com/api/webhooks/200/cloudwatch", "https://discord.com/api/webhooks/600/error"}
+	if len(stub.urls()) != len(want) {
+		t.Fatalf("unexpected requests: %v", stub.urls())
+	}
+	for i := range want {
+		if stub.urls()[i] != want[i] {
+			t.Fatalf("unexpected request order: %v", stub.urls())
+		}
+	}
+}
+
+func TestHandleRequestAutoDetectsAdapter(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "auto")
+	t.Setenv(cloudWatchWebhookEnvVar, "https://discord.com/api/webhooks/400/default")
+	stub := &stubHTTPClient{}
+	useHTTPClient(t, stub)
+
+	events := []string{
+		sampleAlarmMessage,
+		`{"source":"aws.ec2","detail-type":"EC2 Instance State-change Notification","detail":{}}`,
+		`{"webhookURL":"https://discord.com/api/webhooks/500/direct","content":"hello"}`,
+		`{"Records":[{"messageId":"m-1","eventSource":"aws:sqs","body":"{\"content\":\"queued\"}"}]}`,
+	}
+	for _, event := range events {
+		if _, err := HandleRequest(context.Background(), json.RawMessage(event)); err != nil {
+			t.Fatalf("unexpected error for %s: %v", event, err)
+		}
+	}
+
+	want := []string{"https://discord.com/api/webhooks/400/default", "https://discord.com/api/webhooks/400/default", "https://discord.com/api/webhooks/500/direct", "https://discord.com/api/webhooks/400/default"}
+	if strings.Join(stub.urls(), " ") != strings.Join(want, " ") {
+		t.Fatalf("unexpected requests: %v", stub.urls())
+	}
+}
+
+type customAdapter struct{}
+
+func (customAdapter) Transform(json.RawMessage) ([]domain.NotificationPayload, map[string]any, error) {
+	return []domain.NotificationPayload{{WebhookURL: "https://discord.com/api/webhooks/300/custom", Content: "custom"}}, nil, nil
+}
+
+func TestHandleRequestUsesRegisteredAdapter(t *testing.T) {
+	adapter.Register("custom", func(adapter.Config) (adapter.Adapter, error) {
+		return customAdapter{}, nil
+	})
+	adapter.RegisterDetector("custom", func(event json.RawMessage) bool {
+		return strings.Contains(string(event), "in-house")
+	})
+	t.Cleanup(func() {
+		adapter.Register("custom", nil)
+		adapter.RegisterDetector("custom", nil)
+	})
+
+	stub := &stubHTTPClient{}
+	useHTTPClient(t, stub)
+
+	for _, adapterType := range []string{"custom", "auto"} {
+		t.Setenv(adapterTypeEnvVar, adapterType)
+		if _, err := HandleRequest(context.Background(), json.RawMessage(`{"kind":"in-house"}`)); err != nil {
+			t.Fatalf("%s: unexpected error: %v", adapterType, err)
+		}
+	}
+	if len(stub.urls()) != 2 || stub.urls()[0] != "https://discord.com/api/webhooks/300/custom" || stub.urls()[1] != "https://discord.com/api/webhooks/300/custom" {
+		t.Fatalf("unexpected requests: %v", stub.urls())
+	}
+}
+
+func TestHandleRequestNotifiesOnError(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "direct")
+	t.Setenv(errorWebhookEnvVar, "https://discord.com/api/webhooks/600/error")
+
+	stub := &stubHTTPClient{}
+	useHTTPClient(t, stub)
+
+	_, err := HandleRequest(context.Background(), json.RawMessage(`123`))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if stub.req == nil {
+		t.Fatal("expected error notification to be sent")
+	}
+	if stub.req.URL.String() != "https://discord.com/api/webhooks/600/error" {
+		t.Fatalf("unexpected error webhook: %s", stub.req.URL.String())
+	}
+	body, readErr := io.ReadAll(stub.req.Body)
+	if readErr != nil {
+		t.Fatalf("failed to read body: %v", readErr)
+	}
+	if !strings.Contains(string(body), "Failed to process request") {
+		t.Fatalf("expected error message in payload: %s", string(body))
+	}
+}
+
+func TestHandleRequestRedactsSecrets(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "direct")
+	t.Setenv(errorWebhookEnvVar, "https://discord.com/api/webhooks/600/error")
+	t.Setenv(redactKeysEnvVar, "session")
+
+	stub := &stubHTTPClient{}
+	useHTTPClient(t, stub)
+
+	raw := json.RawMessage(`{"webhookURL": "https://discord.com/api/webhooks/500/sekrit", "content": "hi", "password": "hunter2", "session": "abc", "action": "bogus"}`)
+	_, err := HandleRequest(context.Background(), raw)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if len(stub.bodies) != 1 {
+		t.Fatalf("expected an error notification, got %d requests", len(stub.bodies))
+	}
+	for _, secret := range []string{"sekrit", "hunter2", `"abc"`} {
+		if strings.Contains(stub.bodies[0], secret) {
+			t.Fatalf("expected %s to be redacted: %s", secret, stub.bodies[0])
+		}
+	}
+	if !strings.Contains(stub.bodies[0], "webhooks/500/[REDACTED]") {
+		t.Fatalf("expected webhook id to be kept: %s", stub.bodies[0])
+	}
+}
+
+func TestHandleRequestRedactsWebhookErrors(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "direct")
+	stub := &stubHTTPClient{err: &url.Error{Op: "Post", URL: "https://discord.com/api/webhooks/500/sekrit", Err: errors.New("connection reset")}}
+	useHTTPClient(t, stub)
+	t.Setenv(retryMaxAttemptsEnvVar, "1")
+
+	_, err := HandleRequest(context.Background(), json.RawMessage(`{"webhookURL": "https://discord.com/api/webhooks/500/sekrit", "content": "hi"}`))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if strings.Contains(err.Error(), "sekrit") || !strings.Contains(err.Error(), "webhooks/500/[REDACTED]") {
+		t.Fatalf("expected token to be redacted from the returned error: %v", err)
+	}
+}
+
+func TestHandleRequestFailsOnDiscordRejection(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "direct")
+	t.Setenv(errorWebhookEnvVar, "https://discord.com/api/webhooks/600/error")
+
+	stub := &stubHTTPClient{resp: &http.Response{
+		StatusCode: http.StatusNotFound,
+		Body:       io.NopCloser(strings.NewReader(`{"message": "Unknown Webhook", "code": 10015}`)),
+	}}
+	useHTTPClient(t, stub)
+
+	raw := json.RawMessage(`{"webhookURL": "https://discord.com/api/webhooks/500/direct", "content": "hello"}`)
+	_, err := HandleRequest(context.Background(), raw)
+	var apiErr *discord.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected API error, got %v", err)
+	}
+	if apiErr.Code != 10015 {
+		t.Fatalf("unexpected discord error code: %d", apiErr.Code)
+	}
+	if stub.req == nil || stub.req.URL.String() != "https://discord.com/api/webhooks/600/error" {
+		t.Fatalf("expected error notification to be sent")
+	}
+}
+
+func TestBuildNotificationPayloadsUnsupported(t *testing.T) {
+	if _, _, err := buildNotificationPayloads("unknown", settings{}, json.RawMessage(`{}`)); err == nil {
+		t.Fatal("expected unsupported adapter error")
+	}
+}
+
+func TestLoadAdapterConfigAlarmThreads(t *testing.T) {
+	t.Setenv(alarmThreadsEnvVar, "true")
+	t.Setenv(alarmThreadIDsEnvVar, `{"CPUHigh": "42"}`)
+
+	cfg, err := loadAdapterConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.AlarmThreads || cfg.AlarmThreadIDs["CPUHigh"] != "42" {
+		t.Fatalf("unexpected config: %#v", cfg)
+	}
+
+	t.Setenv(alarmThreadIDsEnvVar, `["42"]`)
+	if _, err := loadAdapterConfig(); err == nil {
+		t.Fatal("expected error for invalid thread mapping")
+	}
+}
+
+func TestHandleRequestSendsSilentAlarmStates(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "cloudwatch")
+	t.Setenv(cloudWatchWebhookEnvVar, "https://discord.com/api/webhooks/200/cloudwatch")
+	t.Setenv(alarmSilentStatesEnvVar, "OK, INSUFFICIENT_DATA")
+	stub := &stubHTTPClient{}
+	useHTTPClient(t, stub)
+
+	raw := json.RawMessage(`{"AlarmName":"CPUHigh","NewStateValue":"OK","NewStateReason":"recovered"}`)
+	if _, err := HandleRequest(context.Background(), raw); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(stub.req.Body)
+	if !strings.Contains(string(body), `"flags":4096`) {
+		t.Fatalf("expected OK to be sent silently: %s", body)
+	}
+}
+
+func TestLoadRetryPolicy(t *testing.T) {
+	t.Setenv(retryMaxAttemptsEnvVar, "5")
+	t.Setenv(retryBaseDelayEnvVar, "250ms")
+	t.Setenv(retryMaxDelayEnvVar, "2s")
+
+	policy, err := loadRetryPolicy()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if policy.MaxAttempts != 5 || policy.BaseDelay != 250*time.Millisecond || policy.MaxDelay != 2*time.Second {
+		t.Fatalf("unexpected policy: %#v", policy)
+	}
+	if len(policy.RetryableStatuses) == 0 || policy.Jitter != discord.DefaultRetryPolicy().Jitter {
+		t.Fatalf("expected default retryable statuses and jitter to be kept")
+	}
+
+	t.Setenv(retryJitterEnvVar, "0")
+	t.Setenv(retryStatusesEnvVar, "502, 503,408")
+	policy, err = loadRetryPolicy()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if policy.Jitter != 0 || !reflect.DeepEqual(policy.RetryableStatuses, []int{502, 503, 408}) {
+		t.Fatalf("unexpected jitter or statuses: %#v", policy)
+	}
+
+	for key, value := range map[string]string{
+		retryJitterEnvVar:   "1.5",
+		retryStatusesEnvVar: "503,teapot",
+	} {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv(key, value)
+			if _, err := loadRetryPolicy(); err == nil || !strings.Contains(err.Error(), key) {
+				t.Fatalf("expected %s error: %v", key, err)
+			}
+		})
+	}
+	t.Setenv(retryStatusesEnvVar, "200")
+	if _, err := loadRetryPolicy(); err == nil {
+		t.Fatal("expected error for a non-error status")
+	}
+
+	t.Setenv(retryMaxAttemptsEnvVar, "zero")
+	if _, err := loadRetryPolicy(); err == nil {
+		t.Fatal("expected error for invalid attempts")
+	}
+}
+
+func TestBuildErrorNotificationPayload(t *testing.T) {
+	payload := buildErrorNotificationPayload("https://discord.com/api/webhooks/600/error", json.RawMessage(`{"foo":"bar"}`), map[string]any{"foo": "bar"}, errors.New("boom"))
+	if payload.WebhookURL != "https://discord.com/api/webhooks/600/error" {
+		t.Fatalf("unexpected webhook: %s", payload.WebhookURL)
+	}
+	if payload.AllowedMentions == nil || len(payload.AllowedMentions.Parse) != 0 {
+		t.Fatalf("expected mentions to be disabled")
+	}
+	if len(payload.Embeds) != 1 {
+		t.Fatalf("expected embed to be present")
+	}
+}
+
+const sampleAlarmMessage = `{
+  "AlarmName": "CPUHigh",
+  "AlarmDescription": "CPU usage is high",
+  "AWSAccountId": "123456789012",
+  "NewStateValue": "ALARM",
+  "NewStateReason": "Threshold Crossed",
+  "StateChangeTime": "2024-01-02T03:04:05.678Z",
+  "Region": "us-east-1",
+  "AlarmArn": "arn:aws:cloudwatch:us-east-1:123456789012:alarm:CPUHigh",
+  "OldStateValue": "OK",
+  "Trigger": {
+    "MetricName": "CPUUtilization",
+    "Namespace": "AWS/EC2",
+    "StatisticType": "Statistic",
+    "Statistic": "AVERAGE",
+    "Period": 60,
+    "EvaluationPeriods": 1,
+    "ComparisonOperator": "GreaterThanThreshold",
+    "Threshold": "80",
+    "TreatMissingData": "missing",
+    "Dimensions": [
+      {"name": "InstanceId", "value": "i-1234567890abcdef0"}
+    ]
+  }
+}`
+
+func TestBuildErrorNotificationPayloadAttachesLargeRequests(t *testing.T) {
+	event := map[string]any{"message": strings.Repeat("x", domain.MaxEmbedDescriptionLength)}
+	raw, _ := json.Marshal(event)
+
+	payload := buildErrorNotificationPayload("https://discord.com/api/webhooks/600/error", raw, event, errors.New("boom"))
+	if len(payload.Embeds) != 0 {
+		t.Fatalf("expected oversized request not to be embedded: %#v", payload.Embeds)
+	}
+	if len(payload.Attachments) != 1 {
+		t.Fatalf("expected request attachment, got %d", len(payload.Attachments))
+	}
+	attachment := payload.Attachments[0]
+	if attachment.Name != "request.json" || attachment.ContentType != "application/json" {
+		t.Fatalf("unexpected attachment: %s %s", attachment.Name, attachment.ContentType)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(attachment.Data, &decoded); err != nil || decoded["message"] != event["message"] {
+		t.Fatalf("expected full request in attachment: %v", err)
+	}
+	if err := payload.Validate(); err != nil {
+		t.Fatalf("expected payload within limits: %v", err)
+	}
+}
+
+func TestHandleRequestRoutesAlarms(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "cloudwatch")
+	t.Setenv(cloudWatchWebhookEnvVar, "https://discord.com/api/webhooks/200/cloudwatch")
+	t.Setenv(routingConfigEnvVar, `{"routes":[{"match":{"adapter":"cloudwatch","namespace":"AWS/EC2","paths":{"Trigger.Dimensions.0.value":"i-*"}},"targets":[{"webhook_url":"https://discord.com/api/webhooks/1/infra","username":"Infra","mention_roles":["42"]},{"webhook_url":"https://discord.com/api/webhooks/2/incidents"}]}]}`)
+	stub := &stubHTTPClient{}
+	useHTTPClient(t, stub)
+
+	if _, err := HandleRequest(context.Background(), json.RawMessage(sampleAlarmMessage)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stub.requests) != 2 {
+		t.Fatalf("expected a request per target, got %d", len(stub.requests))
+	}
+	bodies := map[string]string{}
+	for i, req := range stub.requests {
+		bodies[req.URL.String()] = stub.bodies[i]
+	}
+	infra, ok := bodies["https://discord.com/api/webhooks/1/infra"]
+	if _, incidents := bodies["https://discord.com/api/webhooks/2/incidents"]; !ok || !incidents {
+		t.Fatalf("unexpected targets: %s %s", stub.requests[0].URL, stub.requests[1].URL)
+	}
+	if !strings.Contains(infra, `"content":"\u003c@\u002642\u003e `) || !strings.Contains(infra, `"roles":["42"]`) || !strings.Contains(infra, `"username":"Infra"`) {
+		t.Fatalf("expected role mention and username override: %s", infra)
+	}
+	if incidents := bodies["https://discord.com/api/webhooks/2/incidents"]; strings.Contains(incidents, "\u003c@\u0026") || strings.Contains(incidents, `"username":"Infra"`) {
+		t.Fatalf("expected overrides to stay with their own target: %s", incidents)
+	}
+}
+
+func TestHandleRequestMatchesPathsPerSNSRecord(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "cloudwatch")
+	t.Setenv(cloudWatchWebhookEnvVar, "https://discord.com/api/webhooks/200/cloudwatch")
+	t.Setenv(routingConfigEnvVar, `{"routes":[{"match":{"paths":{"$.AlarmName":"cpu-*"}},"targets":[{"webhook_url":"https://discord.com/api/webhooks/1/infra"}]}]}`)
+	stub := &stubHTTPClient{}
+	useHTTPClient(t, stub)
+
+	cpu, _ := json.Marshal(strings.Replace(sampleAlarmMessage, `"CPUHigh"`, `"cpu-high"`, 1))
+	disk, _ := json.Marshal(strings.Replace(sampleAlarmMessage, `"CPUHigh"`, `"disk-full"`, 1))
+	raw := json.RawMessage(`{"Records":[{"Sns":{"Message":` + string(cpu) + `}},{"Sns":{"Message":` + string(disk) + `}}]}`)
+	if _, err := HandleRequest(context.Background(), raw); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"https://discord.com/api/webhooks/1/infra", "https://discord.com/api/webhooks/200/cloudwatch"}
+	if len(stub.urls()) != len(want) || stub.urls()[0] != want[0] || stub.urls()[1] != want[1] {
+		t.Fatalf("expected each record to be matched against its own alarm: %v", stub.urls())
+	}
+}
+
+func TestHandleRequestLoadsRoutingConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routing.json")
+	if err := os.WriteFile(path, []byte(`{"routes":[{"match":{"state":"ALARM"},"targets":[{"webhook_url":"https://discord.com/api/webhooks/1/infra"}]}]}`), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	t.Setenv(adapterTypeEnvVar, "cloudwatch")
+	t.Setenv(cloudWatchWebhookEnvVar, "https://discord.com/api/webhooks/200/cloudwatch")
+	t.Setenv(routingConfigFileEnvVar, path)
+	stub := &stubHTTPClient{}
+	useHTTPClient(t, stub)
+
+	if _, err := HandleRequest(context.Background(), json.RawMessage(sampleAlarmMessage)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stub.req.URL.String() != "https://discord.com/api/webhooks/1/infra" {
+		t.Fatalf("expected routed webhook: %s", stub.req.URL)
+	}
+
+	t.Setenv(routingConfigEnvVar, `{"routes":[{"name":"broken"}]}`)
+	if _, err := HandleRequest(context.Background(), json.RawMessage(sampleAlarmMessage)); err == nil || !strings.Contains(err.Error(), routingConfigEnvVar) {
+		t.Fatalf("expected routing config error: %v", err)
+	}
+}
+
+func TestHandleRequestAppliesMessageTemplates(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "eventbridge")
+	t.Setenv(cloudWatchWebhookEnvVar, "https://discord.com/api/webhooks/700/eventbridge")
+	t.Setenv(templatesEnvVar, `{"eventbridge": {"content": "{{upper .source}}: {{jsonPath \"detail.state\" .}}", "color": "#123456"}}`)
+	stub := &stubHTTPClient{}
+	useHTTPClient(t, stub)
+
+	raw := json.RawMessage(`{"source":"aws.ec2","detail-type":"EC2 Instance State-change Notification","detail":{"state":"stopped"}}`)
+	if _, err := HandleRequest(context.Background(), raw); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(stub.req.Body)
+	if !strings.Contains(string(body), `"content":"AWS.EC2: stopped"`) || !strings.Contains(string(body), `"color":1193046`) {
+		t.Fatalf("expected templated message: %s", body)
+	}
+
+	t.Setenv(templatesEnvVar, `{"eventbridge": {"content": "{{"}}`)
+	if _, err := HandleRequest(context.Background(), raw); err == nil || !strings.Contains(err.Error(), templatesEnvVar) {
+		t.Fatalf("expected template config error: %v", err)
+	}
+}
+
+func TestHandleRequestLocalisesMessages(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "cloudwatch")
+	t.Setenv(cloudWatchWebhookEnvVar, "https://discord.com/api/webhooks/200/cloudwatch")
+	t.Setenv(messageLanguageEnvVar, "ja")
+	stub := &stubHTTPClient{}
+	useHTTPClient(t, stub)
+
+	if _, err := HandleRequest(context.Background(), json.RawMessage(sampleAlarmMessage)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(stub.req.Body)
+	if !strings.Contains(string(body), "CloudWatch アラーム「CPUHigh」の状態: アラーム") || !strings.Contains(string(body), `"name":"変更後の状態"`) {
+		t.Fatalf("expected Japanese message: %s", body)
+	}
+
+	t.Setenv(routingConfigEnvVar, `{"routes":[{"match":{"adapter":"cloudwatch","alarm_name":"CPU*"},"locale":"en"}]}`)
+	stub = &stubHTTPClient{}
+	useHTTPClient(t, stub)
+	if _, err := HandleRequest(context.Background(), json.RawMessage(sampleAlarmMessage)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ = io.ReadAll(stub.req.Body)
+	if !strings.Contains(string(body), `CloudWatch alarm \"CPUHigh\" is alarm`) {
+		t.Fatalf("expected the route locale to override the default: %s", body)
+	}
+
+	t.Setenv(messageLanguageEnvVar, "fr")
+	if _, err := HandleRequest(context.Background(), json.RawMessage(sampleAlarmMessage)); err == nil || !strings.Contains(err.Error(), messageLanguageEnvVar) {
+		t.Fatalf("expected unsupported language error: %v", err)
+	}
+}
+
+func TestHandleRequestUsesDisplayTimezone(t *testing.T) {
+	t.Setenv(adapterTypeEnvVar, "cloudwatch")
+	t.Setenv(cloudWatchWebhookEnvVar, "https://discord.com/api/webhooks/200/cloudwatch")
+	t.Setenv(displayTimezoneEnvVar, "Asia/Tokyo")
+	stub := &stubHTTPClient{}
+	useHTTPClient(t, stub)
+
+	if _, err := HandleRequest(context.Background(), json.RawMessage(sampleAlarmMessage)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(stub.req.Body)
+	if !strings.Contains(string(body), `"value":"2024-01-02 12:04:05 JST (\u003ct:1704164645:R\u003e)"`) {
+		t.Fatalf("expected a changed at field in Asia/Tokyo: %s", body)
+	}
+	if !strings.Contains(string(body), `"timestamp":"2024-01-02T03:04:05.678Z"`) {
+		t.Fatalf("expected an RFC3339 timestamp: %s", body)
+	}
+
+	t.Setenv(displayTimezoneEnvVar, "Mars/Olympus_Mons")
+	if _, err := HandleRequest(context.Background(), json.RawMessage(sampleAlarmMessage)); err == nil || !strings.Contains(err.Error(), displayTimezoneEnvVar) {
+		t.Fatalf("expected invalid timezone error: %v", err)
+	}
+}
+
+func TestBuildErrorNotificationPayloadLocalised(t *testing.T) {
+	t.Setenv(messageLanguageEnvVar, "ja")
+	payload := buildErrorNotificationPayload("https://discord.com/api/webhooks/600/error", json.RawMessage(`{"foo":"bar"}`), map[string]any{"foo": "bar"}, errors.New("boom"))
+	if payload.Content != "リクエストの処理に失敗しました: boom" || payload.Embeds[0].Title != "リクエスト" {
+		t.Fatalf("expected Japanese error notification: %q %q", payload.Content, payload.Embeds[0].Title)
+	}
+}
@@ -0,0 +1,125 @@
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type Key string
+
+const (
+	AlarmSummary            Key = "alarm.summary"
+	AlarmsChanged           Key = "alarm.combined_summary"
+	AlarmDefaultDescription Key = "alarm.default_description"
+	AlarmEvaluationPeriods  Key = "alarm.evaluation_periods"
+	AlarmPeriod             Key = "alarm.period"
+	AlarmMissingData        Key = "alarm.missing_data"
+	FieldAccount            Key = "field.account"
+	FieldRegion             Key = "field.region"
+	FieldOldState           Key = "field.old_state"
+	FieldNewState           Key = "field.new_state"
+	FieldAlarmARN           Key = "field.alarm_arn"
+	FieldTrigger            Key = "field.trigger"
+	FieldDimensions         Key = "field.dimensions"
+	FieldResolvedAfter      Key = "field.resolved_after"
+	FieldChangedAt          Key = "field.changed_at"
+	FieldLogGroup           Key = "field.log_group"
+	FieldLogStream          Key = "field.log_stream"
+	FieldSubscriptionFilter Key = "field.subscription_filters"
+	FieldEvents             Key = "field.events"
+	FieldSource             Key = "field.source"
+	FieldTime               Key = "field.time"
+	FieldResources          Key = "field.resources"
+	ButtonOpenAlarm         Key = "button.open_alarm"
+	ButtonViewMetric        Key = "button.view_metric"
+	ButtonRunbook           Key = "button.runbook"
+	ButtonDashboard         Key = "button.dashboard"
+	StateAlarm              Key = "state.alarm"
+	StateOK                 Key = "state.ok"
+	StateInsufficientData   Key = "state.insufficient_data"
+	StateUnknown            Key = "state.unknown"
+	LogsMatched             Key = "logs.matched"
+	LogsAttached            Key = "logs.attached"
+	EventReceived           Key = "event.received"
+	ElapsedUnderMinute      Key = "elapsed.under_minute"
+	ElapsedMinute           Key = "elapsed.minute"
+	ElapsedMinutes          Key = "elapsed.minutes"
+	ElapsedHours            Key = "elapsed.hours"
+	ElapsedHoursMinutes     Key = "elapsed.hours_minutes"
+	ErrorSummary            Key = "error.summary"
+	ErrorRequest            Key = "error.request"
+)
+
+const DefaultLanguage = "en"
+
+var bundles = map[string]map[Key]string{
+	"en": english,
+	"ja": japanese,
+}
+
+type Catalog struct {
+	language string
+	messages map[Key]string
+}
+
+func Default() Catalog {
+	return Catalog{language: DefaultLanguage, messages: english}
+}
+
+func Lookup(language string) (Catalog, error) {
+	normalised := strings.ToLower(strings.TrimSpace(language))
+	if normalised == "" {
+		return Default(), nil
+	}
+	if base, _, found := strings.Cut(strings.ReplaceAll(normalised, "_", "-"), "-"); found {
+		normalised = base
+	}
+	messages, ok := bundles[normalised]
+	if !ok {
+		return Catalog{}, fmt.Errorf("unsupported language %q (supported: %s)", language, strings.Join(Languages(), ", "))
+	}
+	return Catalog{language: normalised, messages: messages}, nil
+}
+
+func Languages() []string {
+	languages := make([]string, 0, len(bundles))
+	for language := range bundles {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+func (c Catalog) Language() string {
+	if c.language == "" {
+		return DefaultLanguage
+	}
+	return c.language
+}
+
+func (c Catalog) T(key Key, args ...any) string {
+	message, ok := c.messages[key]
+	if !ok {
+		message, ok = english[key]
+	}
+	if !ok {
+		return string(key)
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+func (c Catalog) State(state string) string {
+	switch strings.ToUpper(strings.TrimSpace(state)) {
+	case "ALARM":
+		return c.T(StateAlarm)
+	case "OK":
+		return c.T(StateOK)
+	case "INSUFFICIENT_DATA":
+		return c.T(StateInsufficientData)
+	}
+	return strings.TrimSpace(state)
+}
@@ -0,0 +1,56 @@
+package i18n
+
+import (
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	for language, want := range map[string]string{"": "en", "EN": "en", "ja": "ja", "ja-JP": "ja", " ja_JP ": "ja"} {
+		catalog, err := Lookup(language)
+		if err != nil || catalog.Language() != want {
+			t.Fatalf("Lookup(%q) = %q, %v; want %q", language, catalog.Language(), err, want)
+		}
+	}
+	if _, err := Lookup("fr"); err == nil {
+		t.Fatal("expected unsupported language to fail")
+	}
+}
+
+func TestBundlesCoverEveryKey(t *testing.T) {
+	for language, messages := range bundles {
+		for key := range english {
+			if _, ok := messages[key]; !ok {
+				t.Fatalf("%s bundle is missing %s", language, key)
+			}
+		}
+	}
+}
+
+func TestCatalogTranslates(t *testing.T) {
+	ja, _ := Lookup("ja")
+	if got := ja.T(FieldOldState); got != "変更前の状態" {
+		t.Fatalf("unexpected label: %q", got)
+	}
+	if got := ja.T(LogsMatched, 3, "/aws/lambda/app"); got != ":scroll: /aws/lambda/app で 3 件のログイベントが一致しました" {
+		t.Fatalf("unexpected message: %q", got)
+	}
+	if got := Default().T(AlarmSummary, "CPUHigh", "alarm"); got != `:rotating_light: CloudWatch alarm "CPUHigh" is alarm` {
+		t.Fatalf("unexpected message: %q", got)
+	}
+	if got := (Catalog{}).T(FieldRegion); got != "Region" {
+		t.Fatalf("expected zero catalog to fall back to English: %q", got)
+	}
+}
+
+func TestCatalogState(t *testing.T) {
+	ja, _ := Lookup("ja")
+	cases := map[string]string{"ALARM": "アラーム", "ok": "正常", "INSUFFICIENT_DATA": "データ不足", "CUSTOM": "CUSTOM", "": ""}
+	for state, want := range cases {
+		if got := ja.State(state); got != want {
+			t.Fatalf("State(%q) = %q, want %q", state, got, want)
+		}
+	}
+	if got := Default().State("ALARM"); got != "ALARM" {
+		t.Fatalf("expected English states to keep their names: %q", got)
+	}
+}
@@ -0,0 +1,87 @@
+package i18n
+
+var english = map[Key]string{
+	AlarmSummary:            ":rotating_light: CloudWatch alarm %q is %s",
+	AlarmsChanged:           ":rotating_light: %d CloudWatch alarms changed state",
+	AlarmDefaultDescription: "CloudWatch reported a state change.",
+	AlarmEvaluationPeriods:  "for %d periods",
+	AlarmPeriod:             "period: %ds",
+	AlarmMissingData:        "missing data: %s",
+	FieldAccount:            "Account",
+	FieldRegion:             "Region",
+	FieldOldState:           "Old State",
+	FieldNewState:           "New State",
+	FieldAlarmARN:           "Alarm ARN",
+	FieldTrigger:            "Trigger",
+	FieldDimensions:         "Dimensions",
+	FieldResolvedAfter:      "Resolved After",
+	FieldChangedAt:          "Changed At",
+	FieldLogGroup:           "Log Group",
+	FieldLogStream:          "Log Stream",
+	FieldSubscriptionFilter: "Subscription Filters",
+	FieldEvents:             "Events",
+	FieldSource:             "Source",
+	FieldTime:               "Time",
+	FieldResources:          "Resources",
+	ButtonOpenAlarm:         "Open alarm",
+	ButtonViewMetric:        "View metric",
+	ButtonRunbook:           "Runbook",
+	ButtonDashboard:         "Dashboard",
+	StateAlarm:              "ALARM",
+	StateOK:                 "OK",
+	StateInsufficientData:   "INSUFFICIENT_DATA",
+	StateUnknown:            "unknown",
+	LogsMatched:             ":scroll: %d log event(s) matched in %s",
+	LogsAttached:            "\n…full log attached as %s",
+	EventReceived:           ":satellite: %s from %s",
+	ElapsedUnderMinute:      "less than a minute",
+	ElapsedMinute:           "1 minute",
+	ElapsedMinutes:          "%d minutes",
+	ElapsedHours:            "%dh",
+	ElapsedHoursMinutes:     "%dh %dm",
+	ErrorSummary:            "Failed to process request: %v",
+	ErrorRequest:            "Request",
+}
+
+var japanese = map[Key]string{
+	AlarmSummary:            ":rotating_light: CloudWatch アラーム「%s」の状態: %s",
+	AlarmsChanged:           ":rotating_light: %d 件の CloudWatch アラームの状態が変化しました",
+	AlarmDefaultDescription: "CloudWatch から状態の変化が通知されました。",
+	AlarmEvaluationPeriods:  "%d 期間",
+	AlarmPeriod:             "期間: %d 秒",
+	AlarmMissingData:        "欠落データ: %s",
+	FieldAccount:            "アカウント",
+	FieldRegion:             "リージョン",
+	FieldOldState:           "変更前の状態",
+	FieldNewState:           "変更後の状態",
+	FieldAlarmARN:           "アラーム ARN",
+	FieldTrigger:            "トリガー",
+	FieldDimensions:         "ディメンション",
+	FieldResolvedAfter:      "復旧までの時間",
+	FieldChangedAt:          "変更日時",
+	FieldLogGroup:           "ロググループ",
+	FieldLogStream:          "ログストリーム",
+	FieldSubscriptionFilter: "サブスクリプションフィルター",
+	FieldEvents:             "イベント数",
+	FieldSource:             "ソース",
+	FieldTime:               "日時",
+	FieldResources:          "リソース",
+	ButtonOpenAlarm:         "アラームを開く",
+	ButtonViewMetric:        "メトリクスを表示",
+	ButtonRunbook:           "Runbook",
+	ButtonDashboard:         "ダッシュボード",
+	StateAlarm:              "アラーム",
+	StateOK:                 "正常",
+	StateInsufficientData:   "データ不足",
+	StateUnknown:            "不明",
+	LogsMatched:             ":scroll: %[2]s で %[1]d 件のログイベントが一致しました",
+	LogsAttached:            "\n…全文は %s として添付しています",
+	EventReceived:           ":satellite: %[2]s から %[1]s を受信しました",
+	ElapsedUnderMinute:      "1 分未満",
+	ElapsedMinute:           "1 分",
+	ElapsedMinutes:          "%d 分",
+	ElapsedHours:            "%d 時間",
+	ElapsedHoursMinutes:     "%d 時間 %d 分",
+	ErrorSummary:            "リクエストの処理に失敗しました: %v",
+	ErrorRequest:            "リクエスト",
+}
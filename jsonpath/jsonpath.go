@@ -0,0 +1,45 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func Lookup(root any, path string) (string, bool) {
+	current := root
+	for _, segment := range strings.Split(strings.TrimPrefix(strings.TrimSpace(path), "$."), ".") {
+		switch node := current.(type) {
+		case map[string]any:
+			value, ok := node[segment]
+			if !ok {
+				return "", false
+			}
+			current = value
+		case []any:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node) {
+				return "", false
+			}
+			current = node[index]
+		default:
+			return "", false
+		}
+	}
+
+	switch value := current.(type) {
+	case nil:
+		return "", false
+	case string:
+		return value, true
+	case float64, bool, json.Number:
+		return fmt.Sprint(value), true
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "", false
+		}
+		return string(data), true
+	}
+}
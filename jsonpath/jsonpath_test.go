@@ -0,0 +1,36 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	var event map[string]any
+	if err := json.Unmarshal([]byte(`{"detail":{"state":{"value":"ALARM"},"count":3,"ok":true,"tags":["a","b"],"nested":{"x":1}},"empty":null}`), &event); err != nil {
+		t.Fatalf("failed to decode event: %v", err)
+	}
+
+	cases := []struct {
+		path string
+		want string
+		ok   bool
+	}{
+		{"detail.state.value", "ALARM", true},
+		{"$.detail.state.value", "ALARM", true},
+		{"detail.count", "3", true},
+		{"detail.ok", "true", true},
+		{"detail.tags.1", "b", true},
+		{"detail.nested", `{"x":1}`, true},
+		{"detail.tags.5", "", false},
+		{"detail.missing", "", false},
+		{"detail.state.value.deeper", "", false},
+		{"empty", "", false},
+	}
+	for _, tc := range cases {
+		got, ok := Lookup(event, tc.path)
+		if got != tc.want || ok != tc.ok {
+			t.Fatalf("Lookup(%q) = %q, %v; want %q, %v", tc.path, got, ok, tc.want, tc.ok)
+		}
+	}
+}
@@ -3,8 +3,14 @@
 
 package main
 
-import "github.com/aws/aws-lambda-go/lambda"
+import (
+	_ "time/tzdata"
+
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"lambda-to-discord/handler"
+)
 
 func main() {
-	lambda.Start(HandleRequest)
+	lambda.Start(handler.HandleRequest)
 }
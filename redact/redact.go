@@ -0,0 +1,112 @@
+package redact
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+const Mask = "[REDACTED]"
+
+var (
+	DefaultKeys = []string{"password", "token", "secret", "authorization", "api_key"}
+
+	webhookTokenPattern = regexp.MustCompile(`(/api(?:/v\d+)?/webhooks/\d+/)[^/?#\s"'<>]+`)
+)
+
+func String(s string) string {
+	return webhookTokenPattern.ReplaceAllString(s, "${1}"+Mask)
+}
+
+func Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	message := err.Error()
+	redacted := String(message)
+	if redacted == message {
+		return err
+	}
+	return &redactedError{err: err, message: redacted}
+}
+
+type redactedError struct {
+	err     error
+	message string
+}
+
+func (e *redactedError) Error() string {
+	return e.message
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+type Redactor struct {
+	keys []string
+}
+
+func New(keys ...string) Redactor {
+	var normalized []string
+	for _, key := range append(append([]string(nil), DefaultKeys...), keys...) {
+		if key = normalizeKey(key); key != "" {
+			normalized = append(normalized, key)
+		}
+	}
+	return Redactor{keys: normalized}
+}
+
+func (r Redactor) Value(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		redacted := make(map[string]any, len(v))
+		for key, item := range v {
+			if r.sensitive(key) {
+				redacted[key] = Mask
+				continue
+			}
+			redacted[key] = r.Value(item)
+		}
+		return redacted
+	case []any:
+		redacted := make([]any, len(v))
+		for i, item := range v {
+			redacted[i] = r.Value(item)
+		}
+		return redacted
+	case string:
+		return String(v)
+	default:
+		return value
+	}
+}
+
+func (r Redactor) JSON(data []byte) []byte {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return []byte(String(string(data)))
+	}
+	redacted, err := json.Marshal(r.Value(value))
+	if err != nil {
+		return []byte(String(string(data)))
+	}
+	return redacted
+}
+
+func (r Redactor) sensitive(key string) bool {
+	key = normalizeKey(key)
+	if key == "" {
+		return false
+	}
+	for _, sensitive := range r.keys {
+		if strings.HasSuffix(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeKey(key string) string {
+	return strings.NewReplacer("-", "", "_", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(key)))
+}
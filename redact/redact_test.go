@@ -0,0 +1,88 @@
+package redact
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestString(t *testing.T) {
+	tests := map[string]string{
+		"https://discord.com/api/webhooks/123/secret-token_1":           "https://discord.com/api/webhooks/123/[REDACTED]",
+		"https://discord.com/api/v10/webhooks/123/abc?wait=true":        "https://discord.com/api/v10/webhooks/123/[REDACTED]?wait=true",
+		`Post "https://discord.com/api/webhooks/123/abc/messages/9": x`: `Post "https://discord.com/api/webhooks/123/[REDACTED]/messages/9": x`,
+		"no webhook here": "no webhook here",
+	}
+	for input, want := range tests {
+		if got := String(input); got != want {
+			t.Fatalf("String(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestError(t *testing.T) {
+	if Error(nil) != nil {
+		t.Fatal("expected nil error to stay nil")
+	}
+
+	plain := errors.New("boom")
+	if Error(plain) != plain {
+		t.Fatal("expected errors without tokens to be returned unchanged")
+	}
+
+	urlErr := &url.Error{Op: "Post", URL: "https://discord.com/api/webhooks/123/abc", Err: errors.New("timeout")}
+	err := Error(urlErr)
+	if strings.Contains(err.Error(), "abc") {
+		t.Fatalf("expected token to be masked: %v", err)
+	}
+	var unwrapped *url.Error
+	if !errors.As(err, &unwrapped) {
+		t.Fatal("expected the original error to stay reachable")
+	}
+}
+
+func TestRedactorValue(t *testing.T) {
+	redactor := New("X-Custom")
+	value := map[string]any{
+		"webhookURL":    "https://discord.com/api/webhooks/123/abc",
+		"password":      "hunter2",
+		"Authorization": "Bearer abc",
+		"nested": map[string]any{
+			"access_token": "abc",
+			"x-custom":     "abc",
+			"list":         []any{map[string]any{"client_secret": "abc"}, "keep"},
+		},
+		"content": "hello",
+	}
+
+	redacted := redactor.Value(value).(map[string]any)
+	if redacted["webhookURL"] != "https://discord.com/api/webhooks/123/[REDACTED]" {
+		t.Fatalf("expected webhook token to be masked: %v", redacted["webhookURL"])
+	}
+	if redacted["password"] != Mask || redacted["Authorization"] != Mask || redacted["content"] != "hello" {
+		t.Fatalf("unexpected top level: %#v", redacted)
+	}
+	nested := redacted["nested"].(map[string]any)
+	if nested["access_token"] != Mask || nested["x-custom"] != Mask {
+		t.Fatalf("unexpected nested: %#v", nested)
+	}
+	list := nested["list"].([]any)
+	if list[0].(map[string]any)["client_secret"] != Mask || list[1] != "keep" {
+		t.Fatalf("unexpected list: %#v", list)
+	}
+	if value["password"] != "hunter2" {
+		t.Fatal("expected the original value to be untouched")
+	}
+}
+
+func TestRedactorJSON(t *testing.T) {
+	redactor := New()
+	got := string(redactor.JSON([]byte(`{"token":"abc","content":"hi"}`)))
+	if got != `{"content":"hi","token":"[REDACTED]"}` {
+		t.Fatalf("unexpected json: %s", got)
+	}
+	if got := string(redactor.JSON([]byte(`https://discord.com/api/webhooks/1/abc`))); got != "https://discord.com/api/webhooks/1/[REDACTED]" {
+		t.Fatalf("unexpected fallback: %s", got)
+	}
+}
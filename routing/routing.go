@@ -0,0 +1,282 @@
+package routing
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"lambda-to-discord/domain"
+	"lambda-to-discord/i18n"
+	"lambda-to-discord/jsonpath"
+)
+
+type Config struct {
+	Routes []Route `json:"routes"`
+}
+
+type Route struct {
+	Name     string   `json:"name"`
+	Match    Match    `json:"match"`
+	Targets  []Target `json:"targets"`
+	Continue bool     `json:"continue"`
+	Locale   string   `json:"locale"`
+}
+
+type Match struct {
+	Adapter    Patterns            `json:"adapter"`
+	AlarmName  Patterns            `json:"alarm_name"`
+	Namespace  Patterns            `json:"namespace"`
+	MetricName Patterns            `json:"metric_name"`
+	Account    Patterns            `json:"account"`
+	Region     Patterns            `json:"region"`
+	State      Patterns            `json:"state"`
+	Source     Patterns            `json:"source"`
+	DetailType Patterns            `json:"detail_type"`
+	LogGroup   Patterns            `json:"log_group"`
+	Paths      map[string]Patterns `json:"paths"`
+}
+
+type Target struct {
+	WebhookURL   string   `json:"webhook_url"`
+	Username     string   `json:"username"`
+	AvatarURL    string   `json:"avatar_url"`
+	MentionRoles []string `json:"mention_roles"`
+}
+
+type Patterns []string
+
+func (p *Patterns) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*p = Patterns{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return errors.New("match patterns must be a string or an array of strings")
+	}
+	*p = list
+	return nil
+}
+
+type Router struct {
+	routes []compiledRoute
+}
+
+type compiledRoute struct {
+	route      Route
+	attributes map[string][]*regexp.Regexp
+	paths      map[string][]*regexp.Regexp
+}
+
+func Parse(data []byte) (*Router, error) {
+	data, err := yamlToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode routing config: %w", err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode routing config: %w", err)
+	}
+	return New(cfg)
+}
+
+func yamlToJSON(data []byte) ([]byte, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return data, nil
+	}
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(doc)
+}
+
+func New(cfg Config) (*Router, error) {
+	router := &Router{}
+	var errs []error
+	for i, route := range cfg.Routes {
+		name := route.Name
+		if name == "" {
+			name = fmt.Sprintf("routes[%d]", i)
+		}
+		compiled, err := compileRoute(route)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		router.routes = append(router.routes, compiled)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid routing config: %w", err)
+	}
+	return router, nil
+}
+
+func compileRoute(route Route) (compiledRoute, error) {
+	if len(route.Targets) == 0 && route.Locale == "" {
+		return compiledRoute{}, errors.New("route must have at least one target or a locale")
+	}
+	if route.Locale != "" {
+		if _, err := i18n.Lookup(route.Locale); err != nil {
+			return compiledRoute{}, err
+		}
+	}
+	for i, target := range route.Targets {
+		if target.WebhookURL == "" {
+			continue
+		}
+		if _, err := domain.ParseWebhookURL(target.WebhookURL); err != nil {
+			return compiledRoute{}, fmt.Errorf("targets[%d]: %w", i, err)
+		}
+	}
+
+	compiled := compiledRoute{
+		route:      route,
+		attributes: map[string][]*regexp.Regexp{},
+		paths:      map[string][]*regexp.Regexp{},
+	}
+	for name, patterns := range map[string]Patterns{
+		domain.AttributeAdapter:    route.Match.Adapter,
+		domain.AttributeAlarmName:  route.Match.AlarmName,
+		domain.AttributeNamespace:  route.Match.Namespace,
+		domain.AttributeMetricName: route.Match.MetricName,
+		domain.AttributeAccount:    route.Match.Account,
+		domain.AttributeRegion:     route.Match.Region,
+		domain.AttributeState:      route.Match.State,
+		domain.AttributeSource:     route.Match.Source,
+		domain.AttributeDetailType: route.Match.DetailType,
+		domain.AttributeLogGroup:   route.Match.LogGroup,
+	} {
+		if len(patterns) > 0 {
+			compiled.attributes[name] = compilePatterns(patterns)
+		}
+	}
+	for path, patterns := range route.Match.Paths {
+		if strings.TrimSpace(path) == "" {
+			return compiledRoute{}, errors.New("match paths must not be empty")
+		}
+		compiled.paths[path] = compilePatterns(patterns)
+	}
+	return compiled, nil
+}
+
+func compilePatterns(patterns Patterns) []*regexp.Regexp {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		compiled = append(compiled, globPattern(pattern))
+	}
+	return compiled
+}
+
+func globPattern(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("(?is)^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+func (r *Router) Route(payload domain.NotificationPayload) domain.NotificationPayload {
+	if r == nil || (payload.Action != "" && payload.Action != domain.ActionSend) {
+		return payload
+	}
+
+	var targets []Target
+	for _, route := range r.routes {
+		if len(route.route.Targets) == 0 || !route.matches(payload.Attributes, payload.Event) {
+			continue
+		}
+		targets = append(targets, route.route.Targets...)
+		if !route.route.Continue {
+			break
+		}
+	}
+	if len(targets) == 0 {
+		return payload
+	}
+
+	defaults := payload.Destinations()
+	if len(defaults) == 0 {
+		defaults = []string{payload.WebhookURL}
+	}
+	var destinations []string
+	overrides := map[string]domain.DestinationOverride{}
+	for _, target := range targets {
+		webhookURLs := defaults
+		if webhookURL := strings.TrimSpace(target.WebhookURL); webhookURL != "" {
+			webhookURLs = []string{webhookURL}
+		}
+		for _, webhookURL := range webhookURLs {
+			override, seen := overrides[webhookURL]
+			if !seen {
+				destinations = append(destinations, webhookURL)
+			}
+			overrides[webhookURL] = override.Merge(domain.DestinationOverride{
+				Username:     target.Username,
+				AvatarURL:    target.AvatarURL,
+				MentionRoles: target.MentionRoles,
+			})
+		}
+	}
+
+	payload.WebhookURL = destinations[0]
+	payload.WebhookURLs = destinations[1:]
+	payload.Overrides = overrides
+	return payload
+}
+
+func (r *Router) Locale(attributes map[string]string, event map[string]any) string {
+	if r == nil {
+		return ""
+	}
+	for _, route := range r.routes {
+		if route.route.Locale == "" || !route.matches(attributes, event) {
+			continue
+		}
+		return route.route.Locale
+	}
+	return ""
+}
+
+func (c compiledRoute) matches(attributes map[string]string, event map[string]any) bool {
+	for name, patterns := range c.attributes {
+		value, ok := attributes[name]
+		if !ok || !matchAny(patterns, value) {
+			return false
+		}
+	}
+	for path, patterns := range c.paths {
+		value, ok := jsonpath.Lookup(event, path)
+		if !ok || !matchAny(patterns, value) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchAny(patterns []*regexp.Regexp, value string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,221 @@
+package routing
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"lambda-to-discord/domain"
+)
+
+const testConfig = `{
+  "routes": [
+    {
+      "name": "payments",
+      "match": {"alarm_name": "payments-*", "state": ["alarm", "INSUFFICIENT_DATA"]},
+      "targets": [
+        {"webhook_url": "https://discord.com/api/webhooks/1/payments", "username": "Payments Bot", "mention_roles": ["111"]},
+        {"webhook_url": "https://discord.com/api/webhooks/2/incidents"}
+      ]
+    },
+    {
+      "name": "ec2 events",
+      "match": {"source": "aws.ec2", "paths": {"detail.state": "stopp?d"}},
+      "targets": [{"webhook_url": "https://discord.com/api/webhooks/3/ec2", "avatar_url": "https://example.com/ec2.png"}],
+      "continue": true
+    },
+    {
+      "name": "audit",
+      "match": {"adapter": "eventbridge"},
+      "targets": [{"webhook_url": "https://discord.com/api/webhooks/4/audit"}]
+    }
+  ]
+}`
+
+func testRouter(t *testing.T) *Router {
+	t.Helper()
+	router, err := Parse([]byte(testConfig))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return router
+}
+
+func destinations(payload domain.NotificationPayload) []domain.NotificationPayload {
+	var routed []domain.NotificationPayload
+	for _, destination := range payload.Destinations() {
+		routed = append(routed, payload.ForDestination(destination))
+	}
+	return routed
+}
+
+func TestRouteMatchesAttributes(t *testing.T) {
+	payload := domain.NotificationPayload{
+		WebhookURL:      "https://discord.com/api/webhooks/100/default",
+		Content:         "payments-api is in ALARM",
+		AllowedMentions: domain.NoMentions(),
+		Correlation:     &domain.Correlation{Key: "arn:alarm", Kind: domain.CorrelationOpen, OccurredAt: time.Now()},
+		Attributes:      map[string]string{domain.AttributeAlarmName: "payments-api", domain.AttributeState: "ALARM"},
+	}
+
+	route := testRouter(t).Route(payload)
+	if route.WebhookURL != "https://discord.com/api/webhooks/1/payments" || len(route.WebhookURLs) != 1 || route.WebhookURLs[0] != "https://discord.com/api/webhooks/2/incidents" {
+		t.Fatalf("expected targets as destinations of a single payload: %#v", route)
+	}
+	routed := destinations(route)
+	if len(routed) != 2 {
+		t.Fatalf("expected a payload per target, got %d", len(routed))
+	}
+	first := routed[0]
+	if first.WebhookURL != "https://discord.com/api/webhooks/1/payments" || first.Username != "Payments Bot" {
+		t.Fatalf("unexpected first target: %#v", first)
+	}
+	if first.Content != "<@&111> payments-api is in ALARM" {
+		t.Fatalf("expected role mention: %q", first.Content)
+	}
+	if len(first.AllowedMentions.Roles) != 1 || first.AllowedMentions.Roles[0] != "111" {
+		t.Fatalf("expected mentioned role to be allowed: %#v", first.AllowedMentions)
+	}
+	if first.Correlation.Key != "arn:alarm|1" || routed[1].Correlation.Key != "arn:alarm|2" {
+		t.Fatalf("expected correlation keys per target: %s %s", first.Correlation.Key, routed[1].Correlation.Key)
+	}
+	if routed[1].Content != "payments-api is in ALARM" || routed[1].Username != "" {
+		t.Fatalf("expected second target to be untouched: %#v", routed[1])
+	}
+	if payload.Correlation.Key != "arn:alarm" || len(payload.AllowedMentions.Roles) != 0 {
+		t.Fatal("expected original payload to be untouched")
+	}
+}
+
+func TestRouteStopsAtFirstMatchUnlessContinue(t *testing.T) {
+	payload := domain.NotificationPayload{
+		WebhookURL: "https://discord.com/api/webhooks/100/default",
+		Content:    "ec2",
+		Attributes: map[string]string{domain.AttributeAdapter: "eventbridge", domain.AttributeSource: "aws.ec2"},
+	}
+
+	payload.Event = map[string]any{"detail": map[string]any{"state": "stopped"}}
+	routed := destinations(testRouter(t).Route(payload))
+	if len(routed) != 2 || routed[0].AvatarURL != "https://example.com/ec2.png" || routed[1].WebhookURL != "https://discord.com/api/webhooks/4/audit" {
+		t.Fatalf("expected ec2 and audit targets: %#v", routed)
+	}
+
+	payload.Event = map[string]any{"detail": map[string]any{"state": "running"}}
+	routed = destinations(testRouter(t).Route(payload))
+	if len(routed) != 1 || routed[0].WebhookURL != "https://discord.com/api/webhooks/4/audit" {
+		t.Fatalf("expected only the audit target: %#v", routed)
+	}
+}
+
+func TestRouteKeepsUnmatchedAndNonSendPayloads(t *testing.T) {
+	router := testRouter(t)
+	unmatched := domain.NotificationPayload{WebhookURL: "https://discord.com/api/webhooks/100/default", Attributes: map[string]string{domain.AttributeAlarmName: "orders-api"}}
+	if routed := destinations(router.Route(unmatched)); len(routed) != 1 || routed[0].WebhookURL != unmatched.WebhookURL {
+		t.Fatalf("expected unmatched payload to keep its webhook: %#v", routed)
+	}
+
+	edit := domain.NotificationPayload{Action: domain.ActionEdit, WebhookURL: "https://discord.com/api/webhooks/100/default", Attributes: map[string]string{domain.AttributeAdapter: "eventbridge"}}
+	if routed := destinations(router.Route(edit)); len(routed) != 1 || routed[0].WebhookURL != edit.WebhookURL {
+		t.Fatalf("expected edits not to be rerouted: %#v", routed)
+	}
+
+	absent := domain.NotificationPayload{WebhookURL: "https://discord.com/api/webhooks/100/default", Attributes: map[string]string{domain.AttributeAdapter: "direct"}}
+	wildcard, err := Parse([]byte(`{"routes":[{"match":{"alarm_name":"*"},"targets":[{"webhook_url":"https://discord.com/api/webhooks/1/alarms"}]}]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if routed := wildcard.Route(absent); routed.WebhookURL != absent.WebhookURL || len(routed.WebhookURLs) != 0 {
+		t.Fatalf("expected a wildcard not to match an absent attribute: %#v", routed)
+	}
+
+	var nilRouter *Router
+	if routed := destinations(nilRouter.Route(unmatched)); len(routed) != 1 {
+		t.Fatalf("expected a nil router to pass payloads through: %#v", routed)
+	}
+}
+
+func TestParseRejectsInvalidConfig(t *testing.T) {
+	cases := map[string]string{
+		"not an object":    `routes`,
+		"broken json":      `{"routes": [}`,
+		"broken yaml":      "routes:\n  - match: [",
+		"no targets":       `{"routes":[{"name":"empty","match":{"state":"ALARM"}}]}`,
+		"bad webhook":      `{"routes":[{"targets":[{"webhook_url":"https://example.com/hook"}]}]}`,
+		"bad pattern type": `{"routes":[{"match":{"state":1},"targets":[{}]}]}`,
+		"empty path":       `{"routes":[{"match":{"paths":{"":"x"}},"targets":[{}]}]}`,
+	}
+	for name, config := range cases {
+		if _, err := Parse([]byte(config)); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+
+	_, err := Parse([]byte(`{"routes":[{"name":"empty"}]}`))
+	if err == nil || !strings.Contains(err.Error(), "empty: route must have at least one target") {
+		t.Fatalf("expected route name in error: %v", err)
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+	router, err := Parse([]byte(`# routes for the payments team
+routes:
+  - name: payments
+    match:
+      alarm_name: payments-*
+      state: [ALARM, INSUFFICIENT_DATA]
+      paths:
+        Trigger.Namespace: AWS/*
+    targets:
+      - webhook_url: https://discord.com/api/webhooks/1/payments
+        username: Payments Bot
+        mention_roles: ["111"]
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	payload := domain.NotificationPayload{
+		WebhookURL: "https://discord.com/api/webhooks/100/default",
+		Attributes: map[string]string{domain.AttributeAlarmName: "payments-api", domain.AttributeState: "ALARM"},
+		Event:      map[string]any{"Trigger": map[string]any{"Namespace": "AWS/EC2"}},
+	}
+	routed := destinations(router.Route(payload))
+	if len(routed) != 1 || routed[0].WebhookURL != "https://discord.com/api/webhooks/1/payments" || routed[0].Username != "Payments Bot" {
+		t.Fatalf("unexpected routing from YAML config: %#v", routed)
+	}
+
+	if _, err := Parse([]byte("routes:\n  - targets:\n      - mention_roles: [111]\n")); err == nil {
+		t.Fatal("expected unquoted numeric role ids to be rejected")
+	}
+}
+
+func TestRouterLocale(t *testing.T) {
+	router, err := Parse([]byte(`{"routes": [
+  {"match": {"account": "111111111111"}, "locale": "ja"},
+  {"match": {"alarm_name": "payments-*"}, "targets": [{"webhook_url": "https://discord.com/api/webhooks/1/payments"}]},
+  {"match": {"state": "OK"}, "locale": "en"}
+]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := router.Locale(map[string]string{domain.AttributeAccount: "111111111111", domain.AttributeState: "OK"}, nil); got != "ja" {
+		t.Fatalf("expected the first matching locale to win: %q", got)
+	}
+	if got := router.Locale(map[string]string{domain.AttributeAlarmName: "payments-api", domain.AttributeState: "OK"}, nil); got != "en" {
+		t.Fatalf("expected routes without a locale to be skipped: %q", got)
+	}
+	if got := router.Locale(map[string]string{domain.AttributeState: "ALARM"}, nil); got != "" {
+		t.Fatalf("unexpected locale: %q", got)
+	}
+
+	payload := domain.NotificationPayload{
+		WebhookURL: "https://discord.com/api/webhooks/100/default",
+		Attributes: map[string]string{domain.AttributeAccount: "111111111111", domain.AttributeAlarmName: "payments-api"},
+	}
+	if routed := destinations(router.Route(payload)); len(routed) != 1 || routed[0].WebhookURL != "https://discord.com/api/webhooks/1/payments" {
+		t.Fatalf("expected a locale-only route not to end target routing: %#v", routed)
+	}
+
+	if _, err := Parse([]byte(`{"routes": [{"locale": "fr"}]}`)); err == nil {
+		t.Fatal("expected unsupported locale to fail")
+	}
+}
@@ -0,0 +1,104 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type DynamoDBClient interface {
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+}
+
+type DynamoDBStore struct {
+	client DynamoDBClient
+	table  string
+}
+
+const dynamoDBKeyAttribute = "key"
+
+func NewDynamoDBStore(client DynamoDBClient, table string) *DynamoDBStore {
+	return &DynamoDBStore{client: client, table: table}
+}
+
+func (s *DynamoDBStore) Get(ctx context.Context, key string) (Record, bool, error) {
+	out, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.table),
+		Key:            dynamoDBKey(key),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return Record{}, false, fmt.Errorf("failed to get alarm state: %w", err)
+	}
+	if out == nil || len(out.Item) == 0 {
+		return Record{}, false, nil
+	}
+
+	item := out.Item
+	record := Record{
+		MessageID: stringAttribute(item, "message_id"),
+		ChannelID: stringAttribute(item, "channel_id"),
+		ThreadID:  stringAttribute(item, "thread_id"),
+		WebhookID: stringAttribute(item, "webhook_id"),
+	}
+	for name, field := range map[string]*time.Time{"started_at": &record.StartedAt, "resolved_at": &record.ResolvedAt} {
+		value := stringAttribute(item, name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return Record{}, false, fmt.Errorf("failed to decode alarm state: %w", err)
+		}
+		*field = parsed
+	}
+	return record, true, nil
+}
+
+func (s *DynamoDBStore) Put(ctx context.Context, key string, record Record) error {
+	item := dynamoDBKey(key)
+	for name, value := range map[string]string{
+		"message_id": record.MessageID,
+		"channel_id": record.ChannelID,
+		"thread_id":  record.ThreadID,
+		"webhook_id": record.WebhookID,
+	} {
+		if value != "" {
+			item[name] = &types.AttributeValueMemberS{Value: value}
+		}
+	}
+	for name, value := range map[string]time.Time{"started_at": record.StartedAt, "resolved_at": record.ResolvedAt} {
+		if !value.IsZero() {
+			item[name] = &types.AttributeValueMemberS{Value: value.UTC().Format(time.RFC3339Nano)}
+		}
+	}
+
+	if _, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String(s.table), Item: item}); err != nil {
+		return fmt.Errorf("failed to put alarm state: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoDBStore) Delete(ctx context.Context, key string) error {
+	if _, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{TableName: aws.String(s.table), Key: dynamoDBKey(key)}); err != nil {
+		return fmt.Errorf("failed to delete alarm state: %w", err)
+	}
+	return nil
+}
+
+func dynamoDBKey(key string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{dynamoDBKeyAttribute: &types.AttributeValueMemberS{Value: key}}
+}
+
+func stringAttribute(item map[string]types.AttributeValue, name string) string {
+	if value, ok := item[name].(*types.AttributeValueMemberS); ok {
+		return value.Value
+	}
+	return ""
+}
@@ -0,0 +1,52 @@
+package state
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type Record struct {
+	MessageID  string    `json:"message_id"`
+	ChannelID  string    `json:"channel_id,omitempty"`
+	ThreadID   string    `json:"thread_id,omitempty"`
+	WebhookID  string    `json:"webhook_id"`
+	StartedAt  time.Time `json:"started_at"`
+	ResolvedAt time.Time `json:"resolved_at"`
+}
+
+type Store interface {
+	Get(ctx context.Context, key string) (Record, bool, error)
+	Put(ctx context.Context, key string, record Record) error
+	Delete(ctx context.Context, key string) error
+}
+
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]Record
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: map[string]Record{}}
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string) (Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[key]
+	return record, ok, nil
+}
+
+func (s *MemoryStore) Put(_ context.Context, key string, record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[key] = record
+	return nil
+}
+
+func (s *MemoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
@@ -0,0 +1,129 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type fakeDynamoDBClient struct {
+	items map[string]map[string]types.AttributeValue
+	err   error
+}
+
+func (f *fakeDynamoDBClient) GetItem(_ context.Context, params *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &dynamodb.GetItemOutput{Item: f.items[fakeDynamoDBKey(params.TableName, params.Key)]}, nil
+}
+
+func (f *fakeDynamoDBClient) PutItem(_ context.Context, params *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.items[fakeDynamoDBKey(params.TableName, params.Item)] = params.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (f *fakeDynamoDBClient) DeleteItem(_ context.Context, params *dynamodb.DeleteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	delete(f.items, fakeDynamoDBKey(params.TableName, params.Key))
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func fakeDynamoDBKey(table *string, item map[string]types.AttributeValue) string {
+	return *table + "/" + item["key"].(*types.AttributeValueMemberS).Value
+}
+
+func exerciseStore(t *testing.T, store Store) {
+	t.Helper()
+	ctx := context.Background()
+	record := Record{
+		MessageID:  "111",
+		ChannelID:  "222",
+		ThreadID:   "333",
+		WebhookID:  "100",
+		StartedAt:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		ResolvedAt: time.Date(2024, 1, 2, 3, 46, 5, 0, time.UTC),
+	}
+
+	if _, ok, err := store.Get(ctx, "alarm"); err != nil || ok {
+		t.Fatalf("expected missing record: ok=%v err=%v", ok, err)
+	}
+	if err := store.Put(ctx, "alarm", record); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, ok, err := store.Get(ctx, "alarm")
+	if err != nil || !ok {
+		t.Fatalf("expected stored record: ok=%v err=%v", ok, err)
+	}
+	if got.MessageID != record.MessageID || got.ThreadID != record.ThreadID || got.WebhookID != record.WebhookID || !got.StartedAt.Equal(record.StartedAt) || !got.ResolvedAt.Equal(record.ResolvedAt) {
+		t.Fatalf("unexpected record: %#v", got)
+	}
+	if err := store.Delete(ctx, "alarm"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok, _ := store.Get(ctx, "alarm"); ok {
+		t.Fatal("expected record to be deleted")
+	}
+	if err := store.Delete(ctx, "alarm"); err != nil {
+		t.Fatalf("expected deleting a missing record to succeed: %v", err)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	exerciseStore(t, NewMemoryStore())
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	exerciseStore(t, NewFileStore(path))
+
+	if err := NewFileStore(path).Put(context.Background(), "alarm", Record{MessageID: "111"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	record, ok, err := NewFileStore(path).Get(context.Background(), "alarm")
+	if err != nil || !ok || record.MessageID != "111" {
+		t.Fatalf("expected record to persist across stores: %#v ok=%v err=%v", record, ok, err)
+	}
+}
+
+func TestFileStoreRejectsCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("not json"), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if _, _, err := NewFileStore(path).Get(context.Background(), "alarm"); err == nil {
+		t.Fatal("expected error for corrupt state file")
+	}
+}
+
+func TestDynamoDBStore(t *testing.T) {
+	client := &fakeDynamoDBClient{items: map[string]map[string]types.AttributeValue{}}
+	exerciseStore(t, NewDynamoDBStore(client, "alarms"))
+
+	if err := NewDynamoDBStore(client, "alarms").Put(context.Background(), "alarm", Record{MessageID: "111"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	item := client.items["alarms/alarm"]
+	if _, ok := item["thread_id"]; ok {
+		t.Fatalf("expected empty attributes to be omitted: %#v", item)
+	}
+	if value, ok := item["message_id"].(*types.AttributeValueMemberS); !ok || value.Value != "111" {
+		t.Fatalf("expected message id to be stored as a string attribute: %#v", item)
+	}
+
+	failing := NewDynamoDBStore(&fakeDynamoDBClient{err: errors.New("throttled")}, "alarms")
+	if _, _, err := failing.Get(context.Background(), "alarm"); err == nil {
+		t.Fatal("expected client error to be returned")
+	}
+}
@@ -0,0 +1,142 @@
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"lambda-to-discord/jsonpath"
+)
+
+func funcs() template.FuncMap {
+	return template.FuncMap{
+		"truncate":         truncate,
+		"upper":            func(value any) string { return strings.ToUpper(toString(value)) },
+		"lower":            func(value any) string { return strings.ToLower(toString(value)) },
+		"humanizeDuration": humanizeDuration,
+		"jsonPath":         jsonPath,
+		"default":          defaultValue,
+		"join":             join,
+	}
+}
+
+func truncate(limit int, value any) string {
+	runes := []rune(toString(value))
+	if limit < 0 || len(runes) <= limit {
+		return string(runes)
+	}
+	if limit == 0 {
+		return ""
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
+func humanizeDuration(value any) (string, error) {
+	var d time.Duration
+	switch v := value.(type) {
+	case time.Duration:
+		d = v
+	case float64:
+		d = time.Duration(v * float64(time.Second))
+	case int:
+		d = time.Duration(v) * time.Second
+	case int64:
+		d = time.Duration(v) * time.Second
+	case json.Number:
+		seconds, err := v.Float64()
+		if err != nil {
+			return "", err
+		}
+		d = time.Duration(seconds * float64(time.Second))
+	default:
+		text := strings.TrimSpace(toString(value))
+		if seconds, err := strconv.ParseFloat(text, 64); err == nil {
+			d = time.Duration(seconds * float64(time.Second))
+			break
+		}
+		parsed, err := time.ParseDuration(text)
+		if err != nil {
+			return "", fmt.Errorf("humanizeDuration: cannot convert %q to a duration", text)
+		}
+		d = parsed
+	}
+	return formatDuration(d), nil
+}
+
+func formatDuration(d time.Duration) string {
+	if d < 0 {
+		d = -d
+	}
+	switch {
+	case d < time.Minute:
+		seconds := int(d.Round(time.Second) / time.Second)
+		if seconds == 1 {
+			return "1 second"
+		}
+		return fmt.Sprintf("%d seconds", seconds)
+	case d < time.Hour:
+		minutes := int(d / time.Minute)
+		if minutes == 1 {
+			return "1 minute"
+		}
+		return fmt.Sprintf("%d minutes", minutes)
+	case d < 24*time.Hour:
+		hours, minutes := int(d/time.Hour), int(d%time.Hour/time.Minute)
+		if minutes == 0 {
+			return fmt.Sprintf("%dh", hours)
+		}
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	default:
+		days, hours := int(d/(24*time.Hour)), int(d%(24*time.Hour)/time.Hour)
+		if hours == 0 {
+			return fmt.Sprintf("%dd", days)
+		}
+		return fmt.Sprintf("%dd %dh", days, hours)
+	}
+}
+
+func jsonPath(path string, data any) string {
+	value, _ := jsonpath.Lookup(data, path)
+	return value
+}
+
+func defaultValue(fallback, value any) any {
+	if strings.TrimSpace(toString(value)) == "" {
+		return fallback
+	}
+	return value
+}
+
+func join(sep string, value any) string {
+	items, ok := value.([]any)
+	if !ok {
+		return toString(value)
+	}
+	parts := make([]string, 0, len(items))
+	for _, item := range items {
+		parts = append(parts, toString(item))
+	}
+	return strings.Join(parts, sep)
+}
+
+func toString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	case map[string]any, []any:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,66 @@
+package templates
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTruncate(t *testing.T) {
+	cases := []struct {
+		limit int
+		value any
+		want  string
+	}{
+		{5, "hello", "hello"},
+		{4, "hello", "hel…"},
+		{2, "こんにちは", "こ…"},
+		{0, "hello", ""},
+		{3, nil, ""},
+	}
+	for _, tc := range cases {
+		if got := truncate(tc.limit, tc.value); got != tc.want {
+			t.Fatalf("truncate(%d, %v) = %q, want %q", tc.limit, tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestHumanizeDuration(t *testing.T) {
+	cases := []struct {
+		value any
+		want  string
+	}{
+		{float64(1), "1 second"},
+		{45, "45 seconds"},
+		{json.Number("60"), "1 minute"},
+		{"300", "5 minutes"},
+		{"90m", "1h 30m"},
+		{2 * time.Hour, "2h"},
+		{float64(26 * 60 * 60), "1d 2h"},
+		{"72h", "3d"},
+	}
+	for _, tc := range cases {
+		got, err := humanizeDuration(tc.value)
+		if err != nil || got != tc.want {
+			t.Fatalf("humanizeDuration(%v) = %q, %v; want %q", tc.value, got, err, tc.want)
+		}
+	}
+	if _, err := humanizeDuration("soon"); err == nil {
+		t.Fatal("expected error for invalid duration")
+	}
+}
+
+func TestDefaultAndJoin(t *testing.T) {
+	if got := defaultValue("n/a", ""); got != "n/a" {
+		t.Fatalf("unexpected default: %v", got)
+	}
+	if got := defaultValue("n/a", "value"); got != "value" {
+		t.Fatalf("unexpected default: %v", got)
+	}
+	if got := join(", ", []any{"a", float64(1), true}); got != "a, 1, true" {
+		t.Fatalf("unexpected join: %q", got)
+	}
+	if got := jsonPath("a.0", map[string]any{"a": []any{"x"}}); got != "x" {
+		t.Fatalf("unexpected jsonPath: %q", got)
+	}
+}
@@ -0,0 +1,184 @@
+package templates
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"lambda-to-discord/domain"
+)
+
+type Config map[string]MessageConfig
+
+type MessageConfig struct {
+	Content     string        `json:"content"`
+	Title       string        `json:"title"`
+	Description string        `json:"description"`
+	Color       string        `json:"color"`
+	Fields      []FieldConfig `json:"fields"`
+}
+
+type FieldConfig struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+type Set map[string]*Message
+
+type Message struct {
+	content     *template.Template
+	title       *template.Template
+	description *template.Template
+	color       *template.Template
+	fields      []field
+}
+
+type field struct {
+	name   *template.Template
+	value  *template.Template
+	inline bool
+}
+
+func Parse(data []byte) (Set, error) {
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode message templates: %w", err)
+	}
+	return New(cfg)
+}
+
+func New(cfg Config) (Set, error) {
+	names := make([]string, 0, len(cfg))
+	for name := range cfg {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	set := Set{}
+	var errs []error
+	for _, name := range names {
+		message, err := compile(name, cfg[name])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		set[strings.ToLower(strings.TrimSpace(name))] = message
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid message templates: %w", err)
+	}
+	return set, nil
+}
+
+func (s Set) Lookup(adapter string) *Message {
+	return s[strings.ToLower(strings.TrimSpace(adapter))]
+}
+
+func compile(name string, cfg MessageConfig) (*Message, error) {
+	var errs []error
+	parse := func(part, text string) *template.Template {
+		if strings.TrimSpace(text) == "" {
+			return nil
+		}
+		tmpl, err := template.New(name + "." + part).Funcs(funcs()).Option("missingkey=zero").Parse(text)
+		if err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+		return tmpl
+	}
+
+	message := &Message{
+		content:     parse("content", cfg.Content),
+		title:       parse("title", cfg.Title),
+		description: parse("description", cfg.Description),
+		color:       parse("color", cfg.Color),
+	}
+	for i, f := range cfg.Fields {
+		if strings.TrimSpace(f.Name) == "" || strings.TrimSpace(f.Value) == "" {
+			errs = append(errs, fmt.Errorf("fields[%d] must have a name and a value", i))
+			continue
+		}
+		message.fields = append(message.fields, field{
+			name:   parse(fmt.Sprintf("fields[%d].name", i), f.Name),
+			value:  parse(fmt.Sprintf("fields[%d].value", i), f.Value),
+			inline: f.Inline,
+		})
+	}
+	return message, errors.Join(errs...)
+}
+
+func (m *Message) Content(data any, fallback string) string {
+	if m == nil {
+		return fallback
+	}
+	if content, ok := execute(m.content, data); ok {
+		return content
+	}
+	return fallback
+}
+
+func (m *Message) Embed(embed domain.Embed, data any) domain.Embed {
+	if m == nil {
+		return embed
+	}
+	if title, ok := execute(m.title, data); ok {
+		embed.Title = title
+	}
+	if description, ok := execute(m.description, data); ok {
+		embed.Description = description
+	}
+	if value, ok := execute(m.color, data); ok {
+		if color, err := parseColor(value); err == nil {
+			embed.Color = color
+		}
+	}
+	if len(m.fields) == 0 {
+		return embed
+	}
+
+	fields := make([]domain.EmbedField, 0, len(m.fields))
+	for _, f := range m.fields {
+		name, nameOK := execute(f.name, data)
+		value, valueOK := execute(f.value, data)
+		if !nameOK || !valueOK || strings.TrimSpace(name) == "" || strings.TrimSpace(value) == "" {
+			continue
+		}
+		fields = append(fields, domain.EmbedField{Name: name, Value: value, Inline: f.inline})
+	}
+	embed.Fields = fields
+	return embed
+}
+
+func execute(tmpl *template.Template, data any) (string, bool) {
+	if tmpl == nil {
+		return "", false
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(strings.ReplaceAll(buf.String(), "<no value>", "")), true
+}
+
+func parseColor(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	base := 10
+	switch {
+	case strings.HasPrefix(value, "#"):
+		value, base = value[1:], 16
+	case strings.HasPrefix(strings.ToLower(value), "0x"):
+		value, base = value[2:], 16
+	}
+	color, err := strconv.ParseInt(value, base, 32)
+	if err != nil || color < 0 || color > 0xFFFFFF {
+		return 0, fmt.Errorf("invalid color: %q", value)
+	}
+	return int(color), nil
+}
@@ -0,0 +1,110 @@
+package templates
+
+import (
+	"strings"
+	"testing"
+
+	"lambda-to-discord/domain"
+)
+
+const testTemplates = `{
+  "CloudWatch": {
+    "content": "{{if eq .NewStateValue \"ALARM\"}}:fire:{{else}}:ok:{{end}} {{.AlarmName}} is {{lower .NewStateValue}}",
+    "title": "[{{upper .NewStateValue}}] {{.AlarmName}}",
+    "description": "{{.NewStateReason | truncate 10}}",
+    "color": "{{if eq .NewStateValue \"ALARM\"}}#FF0000{{else}}0x00ff00{{end}}",
+    "fields": [
+      {"name": "Metric", "value": "{{.Trigger.Namespace}}/{{.Trigger.MetricName}}", "inline": true},
+      {"name": "Period", "value": "{{humanizeDuration .Trigger.Period}}", "inline": true},
+      {"name": "Instance", "value": "{{jsonPath \"Trigger.Dimensions.0.value\" .}}"},
+      {"name": "Runbook", "value": "{{.Runbook}}"}
+    ]
+  }
+}`
+
+func alarmData() map[string]any {
+	return map[string]any{
+		"AlarmName":      "CPUHigh",
+		"NewStateValue":  "ALARM",
+		"NewStateReason": "Threshold Crossed: 1 datapoint was greater than the threshold",
+		"Trigger": map[string]any{
+			"Namespace":  "AWS/EC2",
+			"MetricName": "CPUUtilization",
+			"Period":     float64(300),
+			"Dimensions": []any{map[string]any{"name": "InstanceId", "value": "i-123"}},
+		},
+	}
+}
+
+func TestMessageRendersTemplates(t *testing.T) {
+	set, err := Parse([]byte(testTemplates))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	message := set.Lookup("cloudwatch")
+	if message == nil {
+		t.Fatal("expected adapter names to be case-insensitive")
+	}
+
+	if got := message.Content(alarmData(), "fallback"); got != ":fire: CPUHigh is alarm" {
+		t.Fatalf("unexpected content: %q", got)
+	}
+	embed := message.Embed(domain.Embed{Title: "default", URL: "https://example.com", Fields: []domain.EmbedField{{Name: "Old", Value: "x"}}}, alarmData())
+	if embed.Title != "[ALARM] CPUHigh" || embed.Description != "Threshold…" || embed.Color != 0xFF0000 || embed.URL != "https://example.com" {
+		t.Fatalf("unexpected embed: %#v", embed)
+	}
+	want := []domain.EmbedField{
+		{Name: "Metric", Value: "AWS/EC2/CPUUtilization", Inline: true},
+		{Name: "Period", Value: "5 minutes", Inline: true},
+		{Name: "Instance", Value: "i-123"},
+	}
+	if len(embed.Fields) != len(want) {
+		t.Fatalf("expected empty fields to be dropped: %#v", embed.Fields)
+	}
+	for i := range want {
+		if embed.Fields[i] != want[i] {
+			t.Fatalf("unexpected field %d: %#v", i, embed.Fields[i])
+		}
+	}
+}
+
+func TestMessageFallsBackToDefaultRendering(t *testing.T) {
+	set, err := Parse([]byte(`{"cloudwatch": {"title": "{{humanizeDuration .AlarmName}}", "color": "not a color"}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	message := set.Lookup("cloudwatch")
+	if got := message.Content(alarmData(), "fallback"); got != "fallback" {
+		t.Fatalf("expected missing content template to fall back: %q", got)
+	}
+	embed := message.Embed(domain.Embed{Title: "default", Color: 1, Fields: []domain.EmbedField{{Name: "Old", Value: "x"}}}, alarmData())
+	if embed.Title != "default" || embed.Color != 1 || len(embed.Fields) != 1 {
+		t.Fatalf("expected failing templates to keep the default rendering: %#v", embed)
+	}
+
+	var missing *Message
+	if got := set.Lookup("eventbridge"); got != nil {
+		t.Fatalf("expected no template for eventbridge: %#v", got)
+	}
+	if got := missing.Content(nil, "fallback"); got != "fallback" {
+		t.Fatalf("expected nil message to fall back: %q", got)
+	}
+}
+
+func TestParseRejectsInvalidTemplates(t *testing.T) {
+	for name, config := range map[string]string{
+		"not json":     `[]`,
+		"syntax":       `{"cloudwatch": {"content": "{{.AlarmName"}}`,
+		"unknown func": `{"cloudwatch": {"content": "{{shout .AlarmName}}"}}`,
+		"empty field":  `{"cloudwatch": {"fields": [{"name": "Metric"}]}}`,
+	} {
+		if _, err := Parse([]byte(config)); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+
+	_, err := Parse([]byte(`{"eventbridge": {"title": "{{"}}`))
+	if err == nil || !strings.Contains(err.Error(), "eventbridge") {
+		t.Fatalf("expected adapter name in error: %v", err)
+	}
+}